@@ -0,0 +1,130 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // register decoder
+	_ "image/png"  // register decoder
+	"os"
+	"sort"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/leabra/leabra"
+)
+
+// ClassAct is the Output activation for one object class
+type ClassAct struct {
+	Obj int     `desc:"object (LED) number"`
+	Act float32 `desc:"Output unit ActM activation"`
+}
+
+// ClassifyResult is the result of classifying one image file
+type ClassifyResult struct {
+	File    string     `desc:"image file name"`
+	Classes []ClassAct `desc:"object classes ranked by activation, highest first"`
+	Err     string     `json:",omitempty" desc:"error opening image, if any"`
+}
+
+// ClassifyImage runs given image through the TestEnv V1 filters and the
+// network in testing mode, and returns the Output activations ranked from
+// highest to lowest.
+func (ss *Sim) ClassifyImage(img image.Image) []ClassAct {
+	ev := &ss.TestEnv
+	ev.Vis.Filter(img)
+
+	ss.Net.InitExt()
+	out := ss.Net.LayerByName("Output").(leabra.LeabraLayer).AsLeabra()
+	out.SetType(emer.Compare)
+	for sc, lnm := range ss.V1LayNames() {
+		v1 := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		v1.ApplyExt(&ev.Vis.Scale(sc).V1AllTsr)
+	}
+	ss.AlphaCyc(false) // !train
+
+	cls := make([]ClassAct, len(out.Neurons))
+	for ni := range out.Neurons {
+		cls[ni] = ClassAct{Obj: ni, Act: out.Neurons[ni].ActM}
+	}
+	sort.Slice(cls, func(i, j int) bool {
+		return cls[i].Act > cls[j].Act
+	})
+	return cls
+}
+
+// OpenImage opens given image file, inverting it if invert is true, so
+// that dark-on-light drawings match the light-on-dark LED images.
+func OpenImage(fnm string, invert bool) (image.Image, error) {
+	f, err := os.Open(fnm)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	if !invert {
+		return img, nil
+	}
+	bnd := img.Bounds()
+	inv := image.NewGray(bnd)
+	for y := bnd.Min.Y; y < bnd.Max.Y; y++ {
+		for x := bnd.Min.X; x < bnd.Max.X; x++ {
+			g := color.GrayModel.Convert(img.At(x, y)).(color.Gray)
+			inv.SetGray(x, y, color.Gray{Y: 255 - g.Y})
+		}
+	}
+	return inv, nil
+}
+
+// CmdClassify opens the TrainedWts and classifies each of given image files,
+// printing the ranked Output classes, or a JSON array of ClassifyResult
+// if asJSON is true.
+func (ss *Sim) CmdClassify(files []string, invert, asJSON bool) {
+	if len(files) == 0 {
+		fmt.Printf("classify: no image files given\n")
+		return
+	}
+	err := ss.OpenWts(ss.TrainedWts)
+	if err != nil {
+		fmt.Printf("classify: %v\n", err)
+		return
+	}
+	ss.TestEnv.Init(0)
+	var res []ClassifyResult
+	for _, fnm := range files {
+		cr := ClassifyResult{File: fnm}
+		img, err := OpenImage(fnm, invert)
+		if err != nil {
+			cr.Err = err.Error()
+		} else {
+			cr.Classes = ss.ClassifyImage(img)
+		}
+		res = append(res, cr)
+		if asJSON {
+			continue
+		}
+		if cr.Err != "" {
+			fmt.Printf("%s: error: %s\n", fnm, cr.Err)
+			continue
+		}
+		fmt.Printf("%s:\n", fnm)
+		for i, ca := range cr.Classes {
+			fmt.Printf("\t%2d\tObj: %02d\tAct: %.4f\n", i+1, ca.Obj, ca.Act)
+		}
+	}
+	if asJSON {
+		b, err := json.MarshalIndent(res, "", "  ")
+		if err != nil {
+			fmt.Printf("classify: %v\n", err)
+			return
+		}
+		fmt.Println(string(b))
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/emer/etable/agg"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+)
+
+// TestSplits are the names of the object splits that can be tested:
+// all objects, those in the TrainEnv range, or those in the NovelTrainEnv range.
+var TestSplits = []string{"all", "trained", "novel"}
+
+// SetTestSplit sets the TestEnv object range to given split (see TestSplits)
+func (ss *Sim) SetTestSplit(split string) error {
+	switch split {
+	case "all":
+		ss.TestEnv.MinLED = 0
+		ss.TestEnv.MaxLED = len(LEData) - 1
+	case "trained":
+		ss.TestEnv.MinLED = ss.TrainEnv.MinLED
+		ss.TestEnv.MaxLED = ss.TrainEnv.MaxLED
+	case "novel":
+		ss.TestEnv.MinLED = ss.NovelTrainEnv.MinLED
+		ss.TestEnv.MaxLED = ss.NovelTrainEnv.MaxLED
+	default:
+		return fmt.Errorf("test split: %s not one of: %v", split, TestSplits)
+	}
+	return nil
+}
+
+// TstPctErr returns the proportion of errors over all trials in the TstTrlLog
+func (ss *Sim) TstPctErr() float64 {
+	tix := etable.NewIdxView(ss.TstTrlLog)
+	if tix.Len() == 0 {
+		return 0
+	}
+	return agg.Mean(tix, "Err")[0]
+}
+
+// CmdTest loads each of given weights files, runs TestAll on given split of
+// objects, and saves the TstTrlLog and TstEpcLog for each, along with a
+// summary table of accuracy for all weights files.  If ActLog is on, also
+// saves the TstActLog activations and the weights as numpy .npz files,
+// and if CycLog is on, the TstCycLog.
+func (ss *Sim) CmdTest(split string, wtsFiles []string) {
+	if len(wtsFiles) == 0 {
+		if !ss.RunFromTrained {
+			fmt.Printf("test: no weights files given\n")
+			return
+		}
+		wtsFiles = []string{ss.TrainedWts}
+	}
+	err := ss.SetTestSplit(split)
+	if err != nil {
+		fmt.Printf("test: %v\n", err)
+		return
+	}
+	sum := &etable.Table{}
+	ss.ConfigTstSumLog(sum)
+	for _, wf := range wtsFiles {
+		err := ss.OpenWts(wf)
+		if err != nil {
+			fmt.Printf("test: %v\n", err)
+			continue
+		}
+		ss.TestAll()
+
+		base := ss.OutFileName(WtsFileBase(wf) + "_" + split)
+		err = ss.TstTrlLog.SaveCSV(gi.FileName(base+"_tsttrl.tsv"), etable.Tab, etable.Headers)
+		if err != nil {
+			fmt.Printf("test: %v\n", err)
+		}
+		err = ss.TstEpcLog.SaveCSV(gi.FileName(base+"_tstepc.tsv"), etable.Tab, etable.Headers)
+		if err != nil {
+			fmt.Printf("test: %v\n", err)
+		}
+		if ss.CycLog {
+			err = ss.TstCycLog.SaveCSV(gi.FileName(base+"_tstcyc.tsv"), etable.Tab, etable.Headers)
+			if err != nil {
+				fmt.Printf("test: %v\n", err)
+			}
+		}
+		if ss.ActLog {
+			err = ss.SaveActsNpz(base + "_acts.npz")
+			if err != nil {
+				fmt.Printf("test: %v\n", err)
+			}
+			err = ss.SaveWtsNpz(ss.OutFileName(WtsFileBase(wf) + "_wts.npz"))
+			if err != nil {
+				fmt.Printf("test: %v\n", err)
+			}
+		}
+
+		pcterr := ss.TstPctErr()
+		row := sum.Rows
+		sum.SetNumRows(row + 1)
+		sum.SetCellString("Wts", row, wf)
+		sum.SetCellString("Split", row, split)
+		sum.SetCellFloat("NTrials", row, float64(ss.TstTrlLog.Rows))
+		sum.SetCellFloat("PctErr", row, pcterr)
+		sum.SetCellFloat("PctCor", row, 1-pcterr)
+		fmt.Printf("Wts: %s\tSplit: %s\tNTrials: %d\tPctCor: %.4f\n", wf, split, ss.TstTrlLog.Rows, 1-pcterr)
+	}
+	fnm := ss.LogFileName("tstsum")
+	err = sum.SaveCSV(gi.FileName(fnm), etable.Tab, etable.Headers)
+	if err != nil {
+		fmt.Printf("test: %v\n", err)
+		return
+	}
+	fmt.Printf("Saved test summary to: %s\n", fnm)
+}
+
+func (ss *Sim) ConfigTstSumLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstSumLog")
+	dt.SetMetaData("desc", "Summary of test accuracy per weights file")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Wts", etensor.STRING, nil, nil},
+		{"Split", etensor.STRING, nil, nil},
+		{"NTrials", etensor.INT64, nil, nil},
+		{"PctErr", etensor.FLOAT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
@@ -0,0 +1,267 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+	"gonum.org/v1/gonum/stat/distuv"
+)
+
+// CompareMetrics are the RunLog columns compared between conditions by the
+// compare command.  FirstZero is only counted for runs that reached zero
+// errors, and TstPctCor for runs that were tested, so their N can be lower
+// than that of PctCor.
+var CompareMetrics = []string{"PctCor", "FirstZero", "TstPctCor"}
+
+// CompareConf is the confidence level of the confidence intervals
+const CompareConf = 0.95
+
+// CompareCond has the per-run values of each of the CompareMetrics for one
+// condition
+type CompareCond struct {
+	Name string               `desc:"condition name, from the Params, Tag and Arch of the runs, or the run log file"`
+	Vals map[string][]float64 `desc:"per-run values of each metric, NaN-free"`
+}
+
+// RunCondName returns the condition of given row of a RunLog, as its
+// Params, Tag and Arch columns -- those that are present and non-empty
+func RunCondName(dt *etable.Table, row int) string {
+	var nms []string
+	for _, cn := range []string{"Params", "Tag", "Arch"} {
+		if dt.ColIdx(cn) < 0 {
+			continue
+		}
+		if v := dt.CellString(cn, row); v != "" {
+			nms = append(nms, v)
+		}
+	}
+	return strings.Join(nms, "_")
+}
+
+// OpenRunLogs opens given RunLog files and returns the runs in them grouped
+// by condition (Params, Tag and Arch), in order of first appearance.  Rows
+// with no condition, or only the "params" of older run logs that did not
+// record the real params, are grouped by the run log file name instead.
+func OpenRunLogs(fnms []string) ([]*CompareCond, error) {
+	var conds []*CompareCond
+	cmap := make(map[string]*CompareCond)
+	for _, fnm := range fnms {
+		dt := &etable.Table{}
+		err := dt.OpenCSV(gi.FileName(fnm), etable.Tab)
+		if err != nil {
+			return nil, fmt.Errorf("run log: %s: %v", fnm, err)
+		}
+		fcn := strings.TrimSuffix(filepath.Base(fnm), filepath.Ext(fnm))
+		for ri := 0; ri < dt.Rows; ri++ {
+			cn := RunCondName(dt, ri)
+			if cn == "" || cn == "params" {
+				cn = fcn
+			}
+			cd, ok := cmap[cn]
+			if !ok {
+				cd = &CompareCond{Name: cn, Vals: make(map[string][]float64)}
+				cmap[cn] = cd
+				conds = append(conds, cd)
+			}
+			for _, mt := range CompareMetrics {
+				if dt.ColIdx(mt) < 0 {
+					continue
+				}
+				v := dt.CellFloat(mt, ri)
+				if math.IsNaN(v) || ((mt == "FirstZero" || mt == "TstPctCor") && v < 0) {
+					continue
+				}
+				cd.Vals[mt] = append(cd.Vals[mt], v)
+			}
+		}
+	}
+	return conds, nil
+}
+
+// MeanSE returns the mean and standard error of the mean of given values
+func MeanSE(vs []float64) (mean, se float64) {
+	n := float64(len(vs))
+	if n == 0 {
+		return math.NaN(), math.NaN()
+	}
+	for _, v := range vs {
+		mean += v
+	}
+	mean /= n
+	if n < 2 {
+		return mean, math.NaN()
+	}
+	var sumsq float64
+	for _, v := range vs {
+		sumsq += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(sumsq / (n - 1) / n)
+}
+
+// MeanCI returns the CompareConf confidence interval of the mean of given
+// values, from the t distribution
+func MeanCI(vs []float64) (lo, hi float64) {
+	mean, se := MeanSE(vs)
+	if len(vs) < 2 {
+		return math.NaN(), math.NaN()
+	}
+	tc := distuv.StudentsT{Mu: 0, Sigma: 1, Nu: float64(len(vs) - 1)}.Quantile(1 - (1-CompareConf)/2)
+	return mean - tc*se, mean + tc*se
+}
+
+// WelchT returns the Welch t statistic, its degrees of freedom, and the
+// two-sided p value, for the difference of the means of a and b.
+func WelchT(a, b []float64) (t, df, p float64) {
+	if len(a) < 2 || len(b) < 2 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	ma, sa := MeanSE(a)
+	mb, sb := MeanSE(b)
+	va, vb := sa*sa, sb*sb
+	if va+vb == 0 {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+	t = (ma - mb) / math.Sqrt(va+vb)
+	df = (va + vb) * (va + vb) / (va*va/float64(len(a)-1) + vb*vb/float64(len(b)-1))
+	p = 2 * distuv.StudentsT{Mu: 0, Sigma: 1, Nu: df}.CDF(-math.Abs(t))
+	return
+}
+
+// PermTest returns the two-sided p value of a permutation test of the
+// difference of the means of a and b, from nperm random relabelings.
+func PermTest(a, b []float64, nperm int, rnd *rand.Rand) float64 {
+	if len(a) == 0 || len(b) == 0 || nperm <= 0 {
+		return math.NaN()
+	}
+	ma, _ := MeanSE(a)
+	mb, _ := MeanSE(b)
+	obs := math.Abs(ma - mb)
+	all := append(append([]float64{}, a...), b...)
+	na := len(a)
+	nge := 0
+	for pi := 0; pi < nperm; pi++ {
+		rnd.Shuffle(len(all), func(i, j int) { all[i], all[j] = all[j], all[i] })
+		pa, _ := MeanSE(all[:na])
+		pb, _ := MeanSE(all[na:])
+		if math.Abs(pa-pb) >= obs-1e-12 {
+			nge++
+		}
+	}
+	return float64(nge+1) / float64(nperm+1)
+}
+
+// CmdCompare reads given RunLog files and compares the CompareMetrics
+// between the conditions in them: the mean, SE and confidence interval of
+// each, and Welch t-tests and permutation tests (with perms permutations)
+// between each pair of conditions.  Both tables are printed and saved.
+func (ss *Sim) CmdCompare(fnms []string, perms int) {
+	if len(fnms) == 0 {
+		fmt.Printf("compare: no run log files given\n")
+		return
+	}
+	conds, err := OpenRunLogs(fnms)
+	if err != nil {
+		fmt.Printf("compare: %v\n", err)
+		return
+	}
+	sum := &etable.Table{}
+	ss.ConfigCompareLog(sum)
+	for _, mt := range CompareMetrics {
+		for _, cd := range conds {
+			vs := cd.Vals[mt]
+			mean, se := MeanSE(vs)
+			lo, hi := MeanCI(vs)
+			row := sum.Rows
+			sum.SetNumRows(row + 1)
+			sum.SetCellString("Metric", row, mt)
+			sum.SetCellString("Cond", row, cd.Name)
+			sum.SetCellFloat("N", row, float64(len(vs)))
+			sum.SetCellFloat("Mean", row, mean)
+			sum.SetCellFloat("SE", row, se)
+			sum.SetCellFloat("CILo", row, lo)
+			sum.SetCellFloat("CIHi", row, hi)
+			fmt.Printf("%s\t%s\tN: %d\tMean: %.4g\tSE: %.4g\t%g%% CI: [%.4g, %.4g]\n", mt, cd.Name, len(vs), mean, se, 100*CompareConf, lo, hi)
+		}
+	}
+
+	tst := &etable.Table{}
+	ss.ConfigCompareTestLog(tst)
+	rnd := rand.New(rand.NewSource(ss.RndSeed))
+	for _, mt := range CompareMetrics {
+		for ai, ca := range conds {
+			for _, cb := range conds[ai+1:] {
+				a, b := ca.Vals[mt], cb.Vals[mt]
+				ma, _ := MeanSE(a)
+				mb, _ := MeanSE(b)
+				t, df, pw := WelchT(a, b)
+				pp := PermTest(a, b, perms, rnd)
+				row := tst.Rows
+				tst.SetNumRows(row + 1)
+				tst.SetCellString("Metric", row, mt)
+				tst.SetCellString("CondA", row, ca.Name)
+				tst.SetCellString("CondB", row, cb.Name)
+				tst.SetCellFloat("Diff", row, ma-mb)
+				tst.SetCellFloat("T", row, t)
+				tst.SetCellFloat("DF", row, df)
+				tst.SetCellFloat("PWelch", row, pw)
+				tst.SetCellFloat("PPerm", row, pp)
+				fmt.Printf("%s\t%s vs. %s\tDiff: %.4g\tt(%.1f): %.3f\tp Welch: %.4g\tp perm: %.4g\n", mt, ca.Name, cb.Name, ma-mb, df, t, pw, pp)
+			}
+		}
+	}
+
+	fnm := ss.LogFileName("compare")
+	sum.SaveCSV(gi.FileName(fnm), etable.Tab, etable.Headers)
+	fmt.Printf("Saved comparison summary to: %s\n", fnm)
+	fnm = ss.LogFileName("comparetests")
+	tst.SaveCSV(gi.FileName(fnm), etable.Tab, etable.Headers)
+	fmt.Printf("Saved comparison tests to: %s\n", fnm)
+}
+
+func (ss *Sim) ConfigCompareLog(dt *etable.Table) {
+	dt.SetMetaData("name", "CompareLog")
+	dt.SetMetaData("desc", "Mean, SE and confidence interval of each metric for each condition")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Metric", etensor.STRING, nil, nil},
+		{"Cond", etensor.STRING, nil, nil},
+		{"N", etensor.INT64, nil, nil},
+		{"Mean", etensor.FLOAT64, nil, nil},
+		{"SE", etensor.FLOAT64, nil, nil},
+		{"CILo", etensor.FLOAT64, nil, nil},
+		{"CIHi", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+func (ss *Sim) ConfigCompareTestLog(dt *etable.Table) {
+	dt.SetMetaData("name", "CompareTestLog")
+	dt.SetMetaData("desc", "Welch t-test and permutation test of each metric between each pair of conditions")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Metric", etensor.STRING, nil, nil},
+		{"CondA", etensor.STRING, nil, nil},
+		{"CondB", etensor.STRING, nil, nil},
+		{"Diff", etensor.FLOAT64, nil, nil},
+		{"T", etensor.FLOAT64, nil, nil},
+		{"DF", etensor.FLOAT64, nil, nil},
+		{"PWelch", etensor.FLOAT64, nil, nil},
+		{"PPerm", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
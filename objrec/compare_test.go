@@ -0,0 +1,60 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func near(a, b, tol float64) bool {
+	return math.Abs(a-b) <= tol
+}
+
+func TestMeanSE(t *testing.T) {
+	// sample SD of this standard example is sqrt(32/7), so SE = sqrt(4/7)
+	vs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	mean, se := MeanSE(vs)
+	if mean != 5 || !near(se, math.Sqrt(4.0/7.0), 1e-12) {
+		t.Errorf("MeanSE = %g, %g, want 5, %g", mean, se, math.Sqrt(4.0/7.0))
+	}
+	if _, se := MeanSE([]float64{3}); !math.IsNaN(se) {
+		t.Errorf("MeanSE of one value: SE = %g, want NaN", se)
+	}
+}
+
+func TestMeanCI(t *testing.T) {
+	// t(0.975, 7) = 2.364624 from tables
+	vs := []float64{2, 4, 4, 4, 5, 5, 7, 9}
+	hw := 2.364624 * math.Sqrt(4.0/7.0)
+	lo, hi := MeanCI(vs)
+	if !near(lo, 5-hw, 1e-5) || !near(hi, 5+hw, 1e-5) {
+		t.Errorf("MeanCI = [%g, %g], want [%g, %g]", lo, hi, 5-hw, 5+hw)
+	}
+}
+
+func TestWelchT(t *testing.T) {
+	// example 1 of Welch's t-test on Wikipedia: t = -2.46, df = 25.0, p = 0.021
+	a := []float64{27.5, 21.0, 19.0, 23.6, 17.0, 17.9, 16.9, 20.1, 21.9, 22.6, 23.1, 19.6, 19.0, 21.7, 21.4}
+	b := []float64{27.1, 22.0, 20.8, 23.4, 23.4, 23.5, 25.8, 22.0, 24.8, 20.2, 21.9, 22.1, 22.9, 20.5, 24.4}
+	tv, df, p := WelchT(a, b)
+	if !near(tv, -2.4554, 1e-4) || !near(df, 24.9885, 1e-4) || !near(p, 0.021, 0.001) {
+		t.Errorf("WelchT = t %g, df %g, p %g, want t -2.4554, df 24.9885, p 0.021", tv, df, p)
+	}
+}
+
+func TestPermTest(t *testing.T) {
+	// exact p: 2 of the 20 splits of 1..6 into two halves differ by 3 or more
+	rnd := rand.New(rand.NewSource(1))
+	p := PermTest([]float64{1, 2, 3}, []float64{4, 5, 6}, 20000, rnd)
+	if !near(p, 0.1, 0.01) {
+		t.Errorf("PermTest = %g, want 0.1", p)
+	}
+	p = PermTest([]float64{1, 2, 3}, []float64{1, 2, 3}, 1000, rnd)
+	if p != 1 {
+		t.Errorf("PermTest of identical groups = %g, want 1", p)
+	}
+}
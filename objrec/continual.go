@@ -0,0 +1,224 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// ContParams are the parameters for the continual class-incremental learning
+// protocol run by TrainContinual: classes (objects) are introduced in stages,
+// each trained to criterion, with the new classes of each stage after the
+// first presented through the NovelTrainEnv, mixed with the earlier classes
+// per PNovel.
+type ContParams struct {
+	Stages  string  `desc:"number of classes introduced at each stage, space-separated, e.g., '10 5 5'"`
+	MaxEpcs int     `desc:"maximum number of epochs to train each stage"`
+	CritErr float64 `desc:"training epoch PctErr at or below which a stage is at criterion"`
+	CritN   int     `desc:"number of epochs in a row at criterion to finish a stage"`
+	PNovel  float32 `desc:"proportion of trials on the new classes of each stage after the first -- the rest are on earlier classes"`
+}
+
+func (cp *ContParams) Defaults() {
+	cp.Stages = "10 5 5"
+	cp.MaxEpcs = 50
+	cp.CritErr = 0
+	cp.CritN = 2
+	cp.PNovel = 0.5
+}
+
+// StageSizes returns the number of classes introduced at each stage
+func (cp *ContParams) StageSizes() ([]int, error) {
+	var ns []int
+	tot := 0
+	for _, f := range strings.Fields(cp.Stages) {
+		n, err := strconv.Atoi(f)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("continual stages: %q is not a positive number", f)
+		}
+		ns = append(ns, n)
+		tot += n
+	}
+	if len(ns) == 0 {
+		return nil, fmt.Errorf("continual stages: none given")
+	}
+	if tot > len(LEData) {
+		return nil, fmt.Errorf("continual stages: %d classes in total, but only %d objects", tot, len(LEData))
+	}
+	return ns, nil
+}
+
+// TrainContinual runs the continual class-incremental protocol per Cont,
+// from the current run through the remaining runs up to MaxRuns: after each
+// stage is trained, all classes seen so far are tested and their accuracy
+// recorded in the ContLog.
+// The other stopping rules (NZeroStop, MaxMins, TargCosDiff, TstPlateau) are
+// disabled while it runs, as they would end the run in the middle of the
+// protocol, and the next trial would start a new run from scratch.  The
+// LrateSched is also off, as the stages start at varying epochs.
+// The NovelTrainEnv, which presents the new classes of each stage, uses the
+// full TrainEnv.XFormRand, and the object ranges and XFormRand of the envs
+// are restored at the end.
+func (ss *Sim) TrainContinual() error {
+	ns, err := ss.Cont.StageSizes()
+	if err != nil {
+		return err
+	}
+	maxEpcs, nzs, mins, tcd, tpl := ss.MaxEpcs, ss.NZeroStop, ss.MaxMins, ss.TargCosDiff, ss.TstPlateau
+	if nzs > 0 || mins > 0 || tcd > 0 || tpl > 0 {
+		fmt.Printf("continual: NZeroStop, MaxMins, TargCosDiff and TstPlateau are disabled\n")
+	}
+	trnMin, trnMax := ss.TrainEnv.MinLED, ss.TrainEnv.MaxLED
+	novMin, novMax, novXForm := ss.NovelTrainEnv.MinLED, ss.NovelTrainEnv.MaxLED, ss.NovelTrainEnv.XFormRand
+	tstMin, tstMax := ss.TestEnv.MinLED, ss.TestEnv.MaxLED
+	pnovel := ss.PNovel
+	ss.NZeroStop, ss.MaxMins, ss.TargCosDiff, ss.TstPlateau = -1, 0, 0, 0
+	ss.NoLrateSched = true
+	defer func() {
+		ss.MaxEpcs, ss.NZeroStop, ss.MaxMins, ss.TargCosDiff, ss.TstPlateau = maxEpcs, nzs, mins, tcd, tpl
+		ss.NoLrateSched = false
+		ss.PNovel = pnovel
+		ss.TrainEnv.MinLED, ss.TrainEnv.MaxLED = trnMin, trnMax
+		ss.NovelTrainEnv.MinLED, ss.NovelTrainEnv.MaxLED, ss.NovelTrainEnv.XFormRand = novMin, novMax, novXForm
+		ss.TestEnv.MinLED, ss.TestEnv.MaxLED = tstMin, tstMax
+	}()
+	ss.ContLog.SetNumRows(0)
+	ss.StopNow = false
+	for {
+		ss.ContinualRun(ns)
+		if ss.StopNow {
+			return nil
+		}
+		if ss.TrainEnv.Run.Incr() { // we are done!
+			return nil
+		}
+		ss.NewRun()
+	}
+}
+
+// ContinualRun runs the stages of given sizes of the continual protocol in
+// the current run, ending the run when done -- see TrainContinual.
+func (ss *Sim) ContinualRun(ns []int) {
+	ss.MaxEpcs = ss.TrainEnv.Epoch.Cur + len(ns)*(ss.Cont.MaxEpcs+1) // so MaxEpcs never stops a stage
+	st := 0
+	for si, n := range ns {
+		mx := st + n - 1
+		if si == 0 {
+			ss.TrainEnv.MinLED = 0
+			ss.TrainEnv.MaxLED = mx
+			ss.PNovel = 0
+		} else {
+			ss.TrainEnv.MinLED = 0
+			ss.TrainEnv.MaxLED = st - 1
+			ss.NovelTrainEnv.MinLED = st
+			ss.NovelTrainEnv.MaxLED = mx
+			ss.PNovel = ss.Cont.PNovel
+		}
+		fmt.Printf("Run: %d\tStage: %d\tClasses: %d-%d\n", ss.TrainEnv.Run.Cur, si, st, mx)
+		stEpc := ss.TrainEnv.Epoch.Cur
+		ncrit := 0
+		for {
+			ss.NovelTrainEnv.XFormRand = ss.TrainEnv.XFormRand // including any Curric widening
+			ss.TrainEpoch()
+			if ss.StopNow {
+				return
+			}
+			if ss.EpcPctErr <= ss.Cont.CritErr {
+				ncrit++
+			} else {
+				ncrit = 0
+			}
+			if ncrit >= ss.Cont.CritN || ss.TrainEnv.Epoch.Cur-stEpc >= ss.Cont.MaxEpcs {
+				break
+			}
+		}
+		ss.TestEnv.MinLED = 0
+		ss.TestEnv.MaxLED = mx
+		ss.TestAll()
+		if ss.StopNow {
+			return
+		}
+		ss.LogCont(ss.ContLog, si, st, mx, ss.TrainEnv.Epoch.Cur-stEpc, ncrit >= ss.Cont.CritN)
+		st = mx + 1
+	}
+	ss.StopRule = "Continual"
+	ss.RunEnd()
+}
+
+//////////////////////////////////////////////
+//  ContLog
+
+// LogCont adds the test accuracy on each class after given continual stage
+// to the ContLog, from the TstEpcLog: classes up to newSt were learned in
+// earlier stages, and newSt to mx in this one.  ClassPctCor is NaN for
+// classes not yet seen.
+func (ss *Sim) LogCont(dt *etable.Table, stage, newSt, mx, epcs int, crit bool) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	cls := dt.CellTensor("ClassPctCor", row).(*etensor.Float64)
+	for i := range cls.Values {
+		cls.Values[i] = math.NaN()
+	}
+	var all, old, nw, nold, nnew float64
+	tst := ss.TstEpcLog
+	for ri := 0; ri < tst.Rows; ri++ {
+		obj := int(tst.CellFloat("Obj", ri))
+		pc := 1 - tst.CellFloat("PctErr", ri)
+		cls.Values[obj] = pc
+		all += pc
+		if obj < newSt {
+			old += pc
+			nold++
+		} else {
+			nw += pc
+			nnew++
+		}
+	}
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellFloat("Stage", row, float64(stage))
+	dt.SetCellFloat("NClasses", row, float64(mx+1))
+	dt.SetCellFloat("Epochs", row, float64(epcs))
+	if crit {
+		dt.SetCellFloat("Crit", row, 1)
+	} else {
+		dt.SetCellFloat("Crit", row, 0)
+	}
+	dt.SetCellFloat("PctCorAll", row, all/math.Max(nold+nnew, 1))
+	if nold > 0 {
+		dt.SetCellFloat("PctCorOld", row, old/nold)
+	} else {
+		dt.SetCellFloat("PctCorOld", row, math.NaN())
+	}
+	dt.SetCellFloat("PctCorNew", row, nw/math.Max(nnew, 1))
+	fmt.Printf("Stage: %d\tEpochs: %d\tPctCorAll: %.4f\tPctCorOld: %.4f\tPctCorNew: %.4f\n", stage, epcs, dt.CellFloat("PctCorAll", row), dt.CellFloat("PctCorOld", row), dt.CellFloat("PctCorNew", row))
+}
+
+func (ss *Sim) ConfigContLog(dt *etable.Table) {
+	dt.SetMetaData("name", "ContLog")
+	dt.SetMetaData("desc", "Test accuracy on each class after each stage of continual class-incremental learning")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Stage", etensor.INT64, nil, nil},
+		{"NClasses", etensor.INT64, nil, nil},
+		{"Epochs", etensor.INT64, nil, nil},
+		{"Crit", etensor.INT64, nil, nil},
+		{"PctCorAll", etensor.FLOAT64, nil, nil},
+		{"PctCorOld", etensor.FLOAT64, nil, nil},
+		{"PctCorNew", etensor.FLOAT64, nil, nil},
+		{"ClassPctCor", etensor.FLOAT64, []int{len(LEData)}, []string{"Class"}},
+	}
+	dt.SetFromSchema(sch, 0)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"github.com/emer/vision/vxform"
+)
+
+// Curric is a curriculum over the difficulty of the random transforms in the
+// TrainEnv: each run starts with the Full XFormRand ranges shrunk to StartFrac
+// of their extent, which widens by Step every Epochs epochs, and / or
+// whenever the training epoch PctErr falls to ErrThr, until reaching the
+// Full ranges.  Translation and rotation ranges shrink toward 0, and scale
+// toward 1.
+type Curric struct {
+	On        bool        `desc:"use the curriculum -- otherwise TrainEnv always uses its XFormRand ranges"`
+	StartFrac float32     `def:"0.2" min:"0" max:"1" desc:"starting fraction of the Full ranges"`
+	Step      float32     `def:"0.2" min:"0" max:"1" desc:"fraction of the Full ranges added at each widening"`
+	Epochs    int         `desc:"if > 0, widen every this many epochs"`
+	ErrThr    float64     `desc:"if > 0, widen when the training epoch PctErr is at or below this value"`
+	MinEpcs   int         `def:"2" desc:"minimum number of epochs between ErrThr widenings"`
+	Full      vxform.Rand `desc:"full transform ranges that the curriculum ends with -- set from TrainEnv in ConfigEnv"`
+	Frac      float32     `inactive:"+" desc:"current fraction of the Full ranges"`
+	LastEpc   int         `inactive:"+" desc:"epoch at which ranges were last widened"`
+}
+
+func (cr *Curric) Defaults() {
+	cr.StartFrac = 0.2
+	cr.Step = 0.2
+	cr.MinEpcs = 2
+	cr.Frac = 1
+}
+
+// Init starts the curriculum at StartFrac, setting given ranges
+func (cr *Curric) Init(rnd *vxform.Rand) {
+	cr.Frac = cr.StartFrac
+	cr.LastEpc = 0
+	cr.Apply(rnd)
+}
+
+// Apply sets given ranges to the current Frac of the Full ranges
+func (cr *Curric) Apply(rnd *vxform.Rand) {
+	fr := cr.Frac
+	rnd.TransX.Set(fr*cr.Full.TransX.Min, fr*cr.Full.TransX.Max)
+	rnd.TransY.Set(fr*cr.Full.TransY.Min, fr*cr.Full.TransY.Max)
+	rnd.Scale.Set(1+fr*(cr.Full.Scale.Min-1), 1+fr*(cr.Full.Scale.Max-1))
+	rnd.Rot.Set(fr*cr.Full.Rot.Min, fr*cr.Full.Rot.Max)
+}
+
+// Epoch is called at the start of each new training epoch, with the PctErr
+// of the previous one -- widens given ranges if the schedule or the error
+// threshold says to, returning true if so.
+func (cr *Curric) Epoch(epc int, pctErr float64, rnd *vxform.Rand) bool {
+	if !cr.On || cr.Frac >= 1 {
+		return false
+	}
+	nepc := epc - cr.LastEpc
+	sched := cr.Epochs > 0 && nepc >= cr.Epochs
+	thr := cr.ErrThr > 0 && pctErr <= cr.ErrThr && nepc >= cr.MinEpcs
+	if !sched && !thr {
+		return false
+	}
+	cr.Frac += cr.Step
+	if cr.Frac > 1 {
+		cr.Frac = 1
+	}
+	cr.LastEpc = epc
+	cr.Apply(rnd)
+	return true
+}
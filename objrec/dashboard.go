@@ -0,0 +1,87 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	_ "embed" // for DashboardHTML
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// DashboardHTML is the self-contained dashboard page, with no external assets
+//
+//go:embed dashboard.html
+var DashboardHTML []byte
+
+// EventInterval is how often the /events stream checks the logs for new rows
+var EventInterval = 500 * time.Millisecond
+
+// Dashboard serves the dashboard page at /
+func (sv *SimServer) Dashboard(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write(DashboardHTML)
+}
+
+// Events serves a server-sent event stream of the logs shown in the
+// dashboard: "trnepc" with new TrnEpcLog rows, "tsthist" with new TstHistLog
+// rows, "tstepc" with the full TstEpcLog after each test, and "reset" when
+// the logs are reset for a new run.  All current rows are sent on connect.
+// Only the published log snapshots are read, never the live logs.
+func (sv *SimServer) Events(w http.ResponseWriter, r *http.Request) {
+	fl, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	ntrn, nhist := 0, 0
+	tick := time.NewTicker(EventInterval)
+	defer tick.Stop()
+	for {
+		sv.Mu.Lock()
+		trn, hist, tst := sv.Logs["TrnEpcLog"], sv.Logs["TstHistLog"], sv.Logs["TstEpcLog"]
+		sv.Mu.Unlock()
+		if trn.Rows < ntrn || hist.Rows < nhist {
+			ntrn, nhist = 0, 0
+			SendEvent(w, "reset", nil)
+		}
+		if trn.Rows > ntrn {
+			rows := TableRowsJSON(trn, ntrn)
+			SendEvent(w, "trnepc", rows)
+			ntrn += len(rows)
+		}
+		if hist.Rows > nhist {
+			rows := TableRowsJSON(hist, nhist)
+			SendEvent(w, "tsthist", rows)
+			SendEvent(w, "tstepc", TableRowsJSON(tst, 0))
+			nhist += len(rows)
+		}
+		fl.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-tick.C:
+		}
+	}
+}
+
+// SendEvent writes one server-sent event of given name, with data as JSON
+func SendEvent(w http.ResponseWriter, name string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, b)
+}
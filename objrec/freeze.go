@@ -0,0 +1,126 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/emer/emergent/params"
+	"github.com/emer/leabra/leabra"
+)
+
+// LearnPrjnMatches returns true if given projection is selected by given
+// LearnPrjns entry: #Name for the projection name, .Class for a projection
+// class, or a plain layer name for all projections into that layer.
+func LearnPrjnMatches(sel string, pj *leabra.Prjn) bool {
+	switch {
+	case strings.HasPrefix(sel, "#"):
+		return pj.Name() == sel[1:]
+	case strings.HasPrefix(sel, "."):
+		return hasClass(pj.Class(), sel[1:])
+	}
+	return pj.Recv.Name() == sel
+}
+
+// AllPrjns returns all the projections in the network, in receiving layer order
+func (ss *Sim) AllPrjns() []*leabra.Prjn {
+	var pjs []*leabra.Prjn
+	for li := 0; li < ss.Net.NLayers(); li++ {
+		for _, pj := range *ss.Net.Layer(li).RecvPrjns() {
+			pjs = append(pjs, pj.(leabra.LeabraPrjn).AsLeabra())
+		}
+	}
+	return pjs
+}
+
+// FreezePrjns freezes all projections except those selected by LearnPrjns,
+// by applying params that set their Lrate and LrateInit to 0 (so the
+// LrateSched does not unfreeze them), and then verifies that exactly the
+// selected projections will learn.  The selected ones get their original
+// LrateInit, as recorded in LrateInits before any freeze, so FreezePrjns can
+// be called again after Init, and with different LearnPrjns.
+func (ss *Sim) FreezePrjns(setMsg bool) error {
+	if ss.LrateInits == nil {
+		ss.LrateInits = make(map[string]float32)
+	}
+	for _, pj := range ss.AllPrjns() {
+		if pj.Learn.LrateInit > 0 { // not frozen, or reset by params
+			ss.LrateInits[pj.Name()] = pj.Learn.LrateInit
+		}
+	}
+	sels := strings.Fields(ss.LearnPrjns)
+	sht := &params.Sheet{
+		{Sel: "Prjn", Desc: "frozen unless in LearnPrjns",
+			Params: params.Params{
+				"Prjn.Learn.Lrate":     "0",
+				"Prjn.Learn.LrateInit": "0",
+			}},
+	}
+	learn := make(map[string]bool)
+	used := make(map[string]bool)
+	for _, pj := range ss.AllPrjns() {
+		for _, sel := range sels {
+			if !LearnPrjnMatches(sel, pj) {
+				continue
+			}
+			used[sel] = true
+			if learn[pj.Name()] {
+				continue
+			}
+			learn[pj.Name()] = true
+			lr := fmt.Sprintf("%g", ss.LrateInits[pj.Name()])
+			*sht = append(*sht, &params.Sel{Sel: "#" + pj.Name(), Desc: "in LearnPrjns: " + sel,
+				Params: params.Params{
+					"Prjn.Learn.Lrate":     lr,
+					"Prjn.Learn.LrateInit": lr,
+				}})
+		}
+	}
+	ss.Net.ApplyParams(sht, setMsg)
+
+	var errs []string
+	for _, sel := range sels {
+		if !used[sel] {
+			errs = append(errs, fmt.Sprintf("%s matches no projections", sel))
+		}
+	}
+	for _, pj := range ss.AllPrjns() {
+		lrns := pj.Learn.Lrate > 0
+		switch {
+		case learn[pj.Name()] && !lrns:
+			errs = append(errs, fmt.Sprintf("%s should learn but has Lrate 0", pj.Name()))
+		case !learn[pj.Name()] && (lrns || pj.Learn.LrateInit > 0):
+			errs = append(errs, fmt.Sprintf("%s should be frozen but has Lrate %g", pj.Name(), pj.Learn.Lrate))
+		}
+	}
+	fmt.Printf("Learning prjns: %s\n", strings.Join(ss.LearningPrjns(), " "))
+	if len(errs) > 0 {
+		return fmt.Errorf("LearnPrjns: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// LearningPrjns returns the names of the projections with Lrate > 0
+func (ss *Sim) LearningPrjns() []string {
+	var nms []string
+	for _, pj := range ss.AllPrjns() {
+		if pj.Learn.Lrate > 0 {
+			nms = append(nms, pj.Name())
+		}
+	}
+	return nms
+}
+
+// FrozenPrjns returns the names of the projections with Lrate == 0
+func (ss *Sim) FrozenPrjns() []string {
+	var nms []string
+	for _, pj := range ss.AllPrjns() {
+		if pj.Learn.Lrate == 0 {
+			nms = append(nms, pj.Name())
+		}
+	}
+	return nms
+}
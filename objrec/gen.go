@@ -0,0 +1,228 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+	"github.com/goki/gi/gi"
+)
+
+// GenLays are the layers whose top-down generated patterns are recorded
+// in the GenLog, and projected back to image space through their
+// Layer:Image activation-based receptive fields.
+var GenLays = []string{"V4", "IT"}
+
+// GenTrial runs one top-down generation trial for given object: the Output
+// unit for that object is clamped, the V1 layers are clamped to zero (or
+// noise if GenNoise > 0), and the network settles in testing mode, so the V4
+// and IT patterns reflect only what the top-down projections produce.
+func (ss *Sim) GenTrial(obj int) {
+	ss.TestEnv.CurLED = obj
+	ss.TestEnv.SetOutput(obj)
+
+	ss.Net.InitExt()
+	out := ss.Net.LayerByName("Output").(leabra.LeabraLayer).AsLeabra()
+	out.SetType(emer.Input) // note: type must be in place before apply inputs
+	out.ApplyExt(&ss.TestEnv.Output)
+
+	for _, lnm := range ss.V1LayNames() {
+		v1 := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		vt := ss.ValsTsr(lnm + "Gen")
+		vt.SetShape(v1.Shp.Shp, nil, nil)
+		for i := range vt.Values {
+			v := float32(0)
+			if ss.GenNoise > 0 {
+				v = float32(rand.NormFloat64()) * ss.GenNoise
+				if v < 0 {
+					v = 0
+				} else if v > 1 {
+					v = 1
+				}
+			}
+			vt.Values[i] = v
+		}
+		v1.ApplyExt(vt)
+	}
+
+	ss.AlphaCyc(false) // !train
+	out.SetType(emer.Compare)
+}
+
+// GenAll runs GenTrial for each object in the TestEnv range, recording the
+// resulting V4 and IT patterns in GenLog.  If ActRFs have been computed by a
+// prior TestAll, each pattern is also projected into image space.
+func (ss *Sim) GenAll() {
+	ss.StopNow = false
+	dt := ss.GenLog
+	dt.SetNumRows(0)
+	for obj := ss.TestEnv.MinLED; obj <= ss.TestEnv.MaxLED; obj++ {
+		ss.GenTrial(obj)
+		ss.LogGen(dt)
+		if ss.StopNow {
+			break
+		}
+	}
+	if ss.GenView != nil {
+		ss.GenView.UpdateTable()
+	}
+	ss.Stopped()
+}
+
+// ProjectRF projects the given activation pattern of the receiving layer of
+// named activation-based receptive field back into the source space, as the
+// activation-weighted average of each unit's normalized receptive field.
+// Returns false if that receptive field has not been computed.
+func (ss *Sim) ProjectRF(rfnm string, acts *etensor.Float32, proj *etensor.Float32) bool {
+	rf := ss.ActRFs.RFByName(rfnm)
+	if rf == nil || rf.NormRF.Len() == 0 {
+		return false
+	}
+	nu := acts.Len()
+	shp := rf.NormRF.Shp.Shp[acts.NumDims():]
+	proj.SetShape(shp, nil, nil)
+	proj.SetZeros()
+	ns := proj.Len()
+	sum := float32(0)
+	for ui := 0; ui < nu; ui++ {
+		act := acts.Values[ui]
+		if act <= 0 {
+			continue
+		}
+		sum += act
+		rfv := rf.NormRF.Values[ui*ns : (ui+1)*ns]
+		for si := range proj.Values {
+			proj.Values[si] += act * rfv[si]
+		}
+	}
+	if sum > 0 {
+		for si := range proj.Values {
+			proj.Values[si] /= sum
+		}
+	}
+	return true
+}
+
+// LogGen adds the current top-down generated patterns to the GenLog table.
+func (ss *Sim) LogGen(dt *etable.Table) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	dt.SetCellFloat("Obj", row, float64(ss.TestEnv.CurLED))
+	for _, lnm := range GenLays {
+		ly := ss.Net.LayerByName(lnm)
+		vt := ss.ValsTsr(lnm + "Gen")
+		ly.UnitValsTensor(vt, "ActM")
+		dt.SetCellTensor(lnm, row, vt)
+		pt := ss.ValsTsr(lnm + "GenImg")
+		if ss.ProjectRF(lnm+":Image", vt, pt) {
+			dt.SetCellTensor(lnm+":Image", row, pt)
+		}
+	}
+}
+
+func (ss *Sim) ConfigGenLog(dt *etable.Table) {
+	dt.SetMetaData("name", "GenLog")
+	dt.SetMetaData("desc", "Top-down generated patterns for each clamped Output unit")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	vis := &ss.TestEnv.Vis
+	pad := vis.V1sGeom.FiltRt.X
+	isz := []int{vis.ImgSize.Y + 2*pad, vis.ImgSize.X + 2*pad}
+
+	sch := etable.Schema{
+		{"Obj", etensor.INT64, nil, nil},
+	}
+	for _, lnm := range GenLays {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		sch = append(sch, etable.Column{lnm, etensor.FLOAT32, ly.Shp.Shp, nil})
+	}
+	for _, lnm := range GenLays {
+		sch = append(sch, etable.Column{lnm + ":Image", etensor.FLOAT32, isz, []string{"Y", "X"}})
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+// SaveGallery saves the GenLog table, and an image of each object's
+// projected V4 and IT patterns, into given directory.
+func (ss *Sim) SaveGallery(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	dt := ss.GenLog
+	err = dt.SaveCSV(gi.FileName(filepath.Join(dir, "gen.tsv")), etable.Tab, etable.Headers)
+	if err != nil {
+		return err
+	}
+	for row := 0; row < dt.Rows; row++ {
+		obj := int(dt.CellFloat("Obj", row))
+		for _, lnm := range GenLays {
+			tsr := dt.CellTensor(lnm+":Image", row).(*etensor.Float32)
+			fnm := filepath.Join(dir, fmt.Sprintf("gen_%02d_%s.png", obj, lnm))
+			err = SavePNG(TensorToGrey(tsr, false), fnm)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	fmt.Printf("Saved generation gallery to: %s\n", dir)
+	return nil
+}
+
+// TensorToGrey returns a greyscale image of given 2D tensor, normalized to
+// the min-max range of its values.  If topZero is false, the first row of
+// the tensor is the bottom of the image, as in the Vis image tensors.
+func TensorToGrey(tsr *etensor.Float32, topZero bool) *image.Gray {
+	ny := tsr.Dim(0)
+	nx := tsr.Dim(1)
+	min, max := float32(0), float32(0)
+	for i, v := range tsr.Values {
+		if i == 0 || v < min {
+			min = v
+		}
+		if i == 0 || v > max {
+			max = v
+		}
+	}
+	rng := max - min
+	img := image.NewGray(image.Rect(0, 0, nx, ny))
+	for y := 0; y < ny; y++ {
+		iy := y
+		if !topZero {
+			iy = ny - 1 - y
+		}
+		for x := 0; x < nx; x++ {
+			v := float32(0)
+			if rng > 0 {
+				v = (tsr.Value([]int{y, x}) - min) / rng
+			}
+			img.SetGray(x, iy, color.Gray{Y: uint8(v * 255)})
+		}
+	}
+	return img
+}
+
+// SavePNG saves given image to a PNG file
+func SavePNG(img image.Image, fnm string) error {
+	f, err := os.Create(fnm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
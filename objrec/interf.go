@@ -0,0 +1,177 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emer/emergent/emer"
+	"github.com/emer/etable/eplot"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+// NovelStartEpc is the TrainEnv epoch at which novel training starts, after
+// the initial training that produced the TrainedWts -- the learning rate
+// has already been dropped per LrateSched at this point.
+const NovelStartEpc = 40
+
+// PNovelStep is one entry of a PNovel schedule: the PNovel that applies
+// from given epoch of novel training on
+type PNovelStep struct {
+	Epoch  int     `desc:"epoch of novel training from which PNovel applies"`
+	PNovel float32 `desc:"proportion of novel training items"`
+}
+
+// ParsePNovelSched parses given schedule of space-separated epoch:pnovel
+// entries into steps sorted by epoch, checking that epochs are >= 0 and
+// not repeated, and that pnovel values are in 0-1.
+func ParsePNovelSched(sched string) ([]PNovelStep, error) {
+	var steps []PNovelStep
+	for _, ent := range strings.Fields(sched) {
+		es := strings.Split(ent, ":")
+		if len(es) != 2 {
+			return nil, fmt.Errorf("PNovelSched: entry %q is not epoch:pnovel", ent)
+		}
+		epc, err := strconv.Atoi(es[0])
+		if err != nil {
+			return nil, fmt.Errorf("PNovelSched: entry %q: %v", ent, err)
+		}
+		if epc < 0 {
+			return nil, fmt.Errorf("PNovelSched: entry %q: epoch must be >= 0", ent)
+		}
+		p, err := strconv.ParseFloat(es[1], 32)
+		if err != nil {
+			return nil, fmt.Errorf("PNovelSched: entry %q: %v", ent, err)
+		}
+		if !(p >= 0 && p <= 1) {
+			return nil, fmt.Errorf("PNovelSched: entry %q: pnovel must be between 0 and 1", ent)
+		}
+		steps = append(steps, PNovelStep{Epoch: epc, PNovel: float32(p)})
+	}
+	sort.SliceStable(steps, func(i, j int) bool { return steps[i].Epoch < steps[j].Epoch })
+	for i := 1; i < len(steps); i++ {
+		if steps[i].Epoch == steps[i-1].Epoch {
+			return nil, fmt.Errorf("PNovelSched: epoch %d is repeated", steps[i].Epoch)
+		}
+	}
+	return steps, nil
+}
+
+// PNovelAt returns the PNovel to use after given number of epochs of novel
+// training, from the PNovelSteps parsed from the PNovelSched, or 0.5 if
+// there is no schedule, or none of its steps applies yet.
+func (ss *Sim) PNovelAt(nepc int) float32 {
+	pn := float32(0.5)
+	for _, st := range ss.PNovelSteps {
+		if st.Epoch > nepc {
+			break
+		}
+		pn = st.PNovel
+	}
+	return pn
+}
+
+// SetPNovel sets PNovel from the PNovelSched for given number of epochs of
+// novel training
+func (ss *Sim) SetPNovel(nepc int) {
+	pn := ss.PNovelAt(nepc)
+	if pn != ss.PNovel {
+		fmt.Printf("PNovel: %g at novel epoch: %d\n", pn, nepc)
+	}
+	ss.PNovel = pn
+}
+
+// InterfPctCor tests InterfTrls held-out draws of the objects from minLED to
+// maxLED, cycling through the objects in order, with random transforms from
+// the TestEnv, and returns the proportion correct.  Nothing is logged, and
+// the trial stats are not changed.
+func (ss *Sim) InterfPctCor(minLED, maxLED int) float64 {
+	ss.Net.LayerByName("Output").SetType(emer.Compare)
+	nobj := 1 + maxLED - minLED
+	ncor := 0
+	for i := 0; i < ss.InterfTrls; i++ {
+		ss.TestEnv.DoObject(minLED + i%nobj)
+		ss.ApplyInputs(&ss.TestEnv)
+		ss.AlphaCyc(false) // !train
+		if ss.OutputCorrect(ss.TestEnv.CurLED) {
+			ncor++
+		}
+	}
+	return float64(ncor) / float64(ss.InterfTrls)
+}
+
+// OutputCorrect returns true if the most active Output unit in the minus
+// phase is the given correct one.  It has no side effects on the stats.
+func (ss *Sim) OutputCorrect(cor int) bool {
+	out := ss.Net.LayerByName("Output").(leabra.LeabraLayer).AsLeabra()
+	maxi := -1
+	maxAct := float32(0)
+	for ni := range out.Neurons {
+		if act := out.Neurons[ni].ActM; maxi < 0 || act > maxAct {
+			maxi = ni
+			maxAct = act
+		}
+	}
+	return maxi == cor
+}
+
+//////////////////////////////////////////////
+//  InterfLog
+
+// LogInterf tests accuracy separately on the originally trained objects
+// (TrainEnv range) and the novel ones (NovelTrainEnv range), and adds it to
+// the InterfLog, for given number of epochs of novel training.
+func (ss *Sim) LogInterf(dt *etable.Table, nepc int) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	old := ss.InterfPctCor(ss.TrainEnv.MinLED, ss.TrainEnv.MaxLED)
+	nov := ss.InterfPctCor(ss.NovelTrainEnv.MinLED, ss.NovelTrainEnv.MaxLED)
+
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellFloat("Epoch", row, float64(NovelStartEpc+nepc))
+	dt.SetCellFloat("NovEpc", row, float64(nepc))
+	dt.SetCellFloat("PNovel", row, float64(ss.PNovel))
+	dt.SetCellFloat("OldPctCor", row, old)
+	dt.SetCellFloat("NovPctCor", row, nov)
+
+	ss.InterfPlot.GoUpdate()
+}
+
+func (ss *Sim) ConfigInterfLog(dt *etable.Table) {
+	dt.SetMetaData("name", "InterfLog")
+	dt.SetMetaData("desc", "Accuracy on originally trained vs. novel objects over novel training")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"NovEpc", etensor.INT64, nil, nil},
+		{"PNovel", etensor.FLOAT64, nil, nil},
+		{"OldPctCor", etensor.FLOAT64, nil, nil},
+		{"NovPctCor", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+func (ss *Sim) ConfigInterfPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Object Recognition Novel Training Interference Plot"
+	plt.Params.XAxisCol = "NovEpc"
+	plt.SetTable(dt)
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("NovEpc", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("PNovel", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("OldPctCor", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+	plt.SetColParams("NovPctCor", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	return plt
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePNovelSched(t *testing.T) {
+	steps, err := ParsePNovelSched("10:0.25 0:1 5:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PNovelStep{{0, 1}, {5, 0}, {10, 0.25}}
+	if !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %v, want %v", steps, want)
+	}
+	ss := &Sim{PNovelSteps: steps}
+	for _, ts := range []struct {
+		nepc int
+		pn   float32
+	}{{0, 1}, {4, 1}, {5, 0}, {9, 0}, {10, 0.25}, {100, 0.25}} {
+		if pn := ss.PNovelAt(ts.nepc); pn != ts.pn {
+			t.Errorf("PNovelAt(%d) = %g, want %g", ts.nepc, pn, ts.pn)
+		}
+	}
+	ss.PNovelSteps = []PNovelStep{{5, 0.25}}
+	if pn := ss.PNovelAt(0); pn != 0.5 {
+		t.Errorf("PNovelAt before first step = %g, want 0.5", pn)
+	}
+
+	if steps, err := ParsePNovelSched(""); err != nil || len(steps) != 0 {
+		t.Errorf("empty schedule = %v, %v, want no steps", steps, err)
+	}
+	for _, bad := range []string{"5", "a:0.5", "5:x", "-1:0.5", "0:1.5", "0:-0.1", "0:NaN", "0:0.5 0:0.25"} {
+		if _, err := ParsePNovelSched(bad); err == nil {
+			t.Errorf("ParsePNovelSched(%q): no error", bad)
+		}
+	}
+}
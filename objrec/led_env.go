@@ -28,6 +28,8 @@ type LEDEnv struct {
 	PrvLED    int             `inactive:"+" desc:"previous LED number that was drawn"`
 	XFormRand vxform.Rand     `desc:"random transform parameters"`
 	XForm     vxform.XForm    `desc:"current -- prev transforms"`
+	Bank      V1Bank          `desc:"optional bank of V1 filter outputs, to skip rendering and filtering repeated stimuli"`
+	Prefetch  Prefetch        `desc:"optional background generation of upcoming stimuli in Step -- not used with Bank"`
 	Run       env.Ctr         `view:"inline" desc:"current run of model as provided during Init"`
 	Epoch     env.Ctr         `view:"inline" desc:"number of times through Seq.Max number of sequences"`
 	Trial     env.Ctr         `view:"inline" desc:"trial is the step counter within epoch"`
@@ -55,6 +57,10 @@ func (ev *LEDEnv) States() env.Elements {
 		{"V1", sz, nms},
 		{"Output", []int{4, 5}, []string{"Y", "X"}},
 	}
+	for sc := 1; sc < ev.Vis.NScales; sc++ {
+		vs := ev.Vis.Scale(sc)
+		els = append(els, env.Element{V1ScaleLayName(sc), vs.V1AllTsr.Shapes(), vs.V1AllTsr.DimNames()})
+	}
 	return els
 }
 
@@ -68,6 +74,9 @@ func (ev *LEDEnv) State(element string) etensor.Tensor {
 	case "Output":
 		return &ev.Output
 	}
+	if vs := ev.Vis.ScaleByName(element); vs != nil {
+		return &vs.V1AllTsr
+	}
 	return nil
 }
 
@@ -94,6 +103,8 @@ func (ev *LEDEnv) Init(run int) {
 	ev.Trial.Init()
 	ev.Run.Cur = run
 	ev.Trial.Cur = -1 // init state -- key so that first Step() = 0
+	ev.Bank.Reset()
+	ev.Prefetch.Stop() // new sequence is started by the sim, with Prefetch.Init
 	ev.Output.SetShape([]int{4, 5}, nil, []string{"Y", "X"})
 }
 
@@ -102,6 +113,20 @@ func (ev *LEDEnv) Step() bool {
 	if ev.Trial.Incr() { // if true, hit max, reset to 0
 		ev.Epoch.Incr()
 	}
+	if ev.Prefetch.Workers > 0 && ev.Bank.N == 0 {
+		ev.Prefetch.Next(ev)
+		return true
+	}
+	if ev.Bank.N > 0 {
+		led := ev.RndLED()
+		if ev.Bank.Full(ev, led) {
+			ev.SetLED(led) // from the bank: no need to draw
+		} else {
+			ev.DrawLED(led)
+		}
+		ev.FilterImg()
+		return true
+	}
 	ev.DrawRndLED()
 	ev.FilterImg()
 	// debug only:
@@ -145,24 +170,37 @@ func (ev *LEDEnv) SetOutput(out int) {
 	ev.Output.SetFloat1D(out, 1)
 }
 
+// RndLED returns a new random LED in the MinLED to MaxLED range
+func (ev *LEDEnv) RndLED() int {
+	rng := 1 + ev.MaxLED - ev.MinLED
+	return ev.MinLED + rand.Intn(rng)
+}
+
 // DrawRndLED picks a new random LED and draws it
 func (ev *LEDEnv) DrawRndLED() {
-	rng := 1 + ev.MaxLED - ev.MinLED
-	led := ev.MinLED + rand.Intn(rng)
-	ev.DrawLED(led)
+	ev.DrawLED(ev.RndLED())
 }
 
 // DrawLED draw specified LED
 func (ev *LEDEnv) DrawLED(led int) {
 	ev.Draw.Clear()
 	ev.Draw.DrawLED(led)
+	ev.SetLED(led)
+}
+
+// SetLED sets the current LED and the Output for it, without drawing it
+func (ev *LEDEnv) SetLED(led int) {
 	ev.PrvLED = ev.CurLED
 	ev.CurLED = led
 	ev.SetOutput(ev.CurLED)
 }
 
-// FilterImg filters the image from LED
+// FilterImg filters the image from LED, or gets it from the Bank if on
 func (ev *LEDEnv) FilterImg() {
+	if ev.Bank.N > 0 {
+		ev.Bank.Filter(ev)
+		return
+	}
 	ev.XFormRand.Gen(&ev.XForm)
 	img := ev.XForm.Image(ev.Draw.Image)
 	ev.Vis.Filter(img)
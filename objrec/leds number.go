@@ -11,6 +11,9 @@ import (
 	"github.com/goki/gi/girl"
 )
 
+// LEDSet is the name of the stimulus set drawn by LEDraw
+const LEDSet = "number"
+
 // LEDraw renders old-school "LED" style "letters" composed of a set of horizontal
 // and vertical elements.  All possible such combinations of 3 out of 6 line segments are created.
 // Renders using SVG.
@@ -0,0 +1,233 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"github.com/emer/emergent/params"
+	"github.com/emer/emergent/prjn"
+	"github.com/emer/vision/gabor"
+	"github.com/emer/vision/kwta"
+	"github.com/emer/vision/vfilter"
+	"github.com/emer/vision/vxform"
+)
+
+// ManifestEnv records the configuration of one environment in the RunManifest
+type ManifestEnv struct {
+	MinLED    int         `desc:"minimum LED number drawn"`
+	MaxLED    int         `desc:"maximum LED number drawn"`
+	Trials    int         `desc:"number of trials per epoch"`
+	XFormRand vxform.Rand `desc:"random transform parameters"`
+}
+
+// ManifestVis records the visual filtering parameters in the RunManifest
+type ManifestVis struct {
+	V1sGabor      gabor.Filter    `desc:"V1 simple gabor filter parameters"`
+	V1sGeom       vfilter.Geom    `desc:"geometry of input, output for V1 simple-cell processing"`
+	V1sNeighInhib kwta.NeighInhib `desc:"neighborhood inhibition for V1s"`
+	V1sKWTA       kwta.KWTA       `desc:"kwta parameters for V1s"`
+	ImgSize       image.Point     `desc:"target image size"`
+	NScales       int             `desc:"number of V1 filtering scales"`
+	Coarse        []gabor.Filter  `desc:"V1 simple gabor filter parameters for each of the coarser scales"`
+	LEDWidth      float32         `desc:"line width of LEDs"`
+	LEDSize       float32         `desc:"size of LEDs as proportion of image size"`
+	LEDImgSize    image.Point     `desc:"size of rendered LED image"`
+}
+
+// ManifestLayer records the configuration of one layer in the RunManifest
+type ManifestLayer struct {
+	Name  string   `desc:"layer name"`
+	Type  string   `desc:"layer type"`
+	Shape []int    `desc:"layer shape"`
+	Prjns []string `desc:"receiving projections, as name: pattern class"`
+}
+
+// RunManifest records everything needed to trace the results in a run
+// directory back to how they were produced, and to reproduce them.
+type RunManifest struct {
+	Args          []string          `desc:"full command line"`
+	Flags         map[string]string `desc:"value of every command line flag, including defaults"`
+	Note          string            `desc:"user note from -note flag"`
+	Tag           string            `desc:"extra tag added to file names"`
+	ParamSet      string            `desc:"additional ParamSet applied on top of Base"`
+	Arch          string            `desc:"architecture / topography variant label"`
+	RndSeed       int64             `desc:"random seed set at Init -- all runs follow from this"`
+	MaxRuns       int               `desc:"number of runs"`
+	MaxEpcs       int               `desc:"maximum number of epochs per run"`
+	MaxTrls       int               `desc:"number of training trials per epoch"`
+	NZeroStop     int               `desc:"number of zero-error epochs to stop after, if > 0"`
+	MaxMins       float64           `desc:"wall-clock minutes per run to stop after, if > 0"`
+	TstPlateau    int               `desc:"number of epochs without testing improvement to stop after, if > 0"`
+	TstPlateauTol float64           `desc:"minimum testing improvement over TstPlateau epochs"`
+	TargCosDiff   float64           `desc:"epoch CosDiff to stop at, if > 0"`
+	Curric        Curric            `desc:"curriculum over the TrainEnv transform ranges"`
+	PNovel        float32           `desc:"proportion of novel training items"`
+	Novel         bool              `desc:"true if training novel items from the trained weights"`
+	PNovelSched   string            `desc:"schedule of PNovel over epochs of novel training"`
+	LEDSet        string            `desc:"name of the stimulus set compiled in"`
+	TrainEnv      ManifestEnv       `desc:"training environment"`
+	NovelEnv      ManifestEnv       `desc:"novel items training environment"`
+	TestEnv       ManifestEnv       `desc:"testing environment"`
+	Vis           ManifestVis       `desc:"visual filtering and drawing parameters"`
+	Layers        []ManifestLayer   `desc:"network architecture"`
+	V1V4Prjn      *prjn.PoolTile    `desc:"V1 to V4 projection pattern"`
+	V1ITPrjn      *prjn.PoolTile    `desc:"V1 to IT projection pattern"`
+	Cont          ContParams        `desc:"continual class-incremental learning protocol parameters, used by the continual command"`
+	V1Bank        int               `desc:"number of V1 filtered samples per object in the training bank, if > 0"`
+	Prefetch      int               `desc:"number of background workers generating training stimuli, if > 0"`
+	LearnPrjns    string            `desc:"projections selected to learn, if set -- all others frozen"`
+	Learning      []string          `desc:"projections that learn, with Lrate > 0 after params are applied"`
+	Frozen        []string          `desc:"projections that are frozen, with Lrate = 0 after params are applied"`
+	Params        *params.Set       `desc:"effective merged parameters"`
+	StartTime     time.Time         `desc:"time when run started"`
+	EndTime       time.Time         `desc:"time when run ended -- zero if still running or killed"`
+	Interrupted   bool              `desc:"true if run was stopped early by an interrupt signal"`
+	Host          string            `desc:"host name"`
+	GoVersion     string            `desc:"Go version used to build"`
+	GOOS          string            `desc:"operating system"`
+	GOARCH        string            `desc:"architecture"`
+	MainModule    string            `desc:"main module path and version"`
+	Deps          []string          `desc:"module dependencies as path@version"`
+}
+
+// MakeRunDir creates a new run directory under given root directory, named
+// with the network, RunName and current time, and sets RunDir to it so that
+// all output files are saved there.  If a run with the same name was started
+// within the same second, a numeric suffix keeps the directories separate.
+func (ss *Sim) MakeRunDir(root string) error {
+	err := os.MkdirAll(root, 0755)
+	if err != nil {
+		return err
+	}
+	base := filepath.Join(root, ss.Net.Nm+"_"+ss.RunName()+"_"+time.Now().Format("20060102_150405"))
+	dir := base
+	for i := 2; ; i++ {
+		err = os.Mkdir(dir, 0755)
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		dir = fmt.Sprintf("%s_%d", base, i)
+	}
+	ss.RunDir = dir
+	fmt.Printf("Saving all output to run directory: %s\n", dir)
+	return nil
+}
+
+// OutFileName returns given file name within the RunDir, if set
+func (ss *Sim) OutFileName(fnm string) string {
+	if ss.RunDir == "" {
+		return fnm
+	}
+	return filepath.Join(ss.RunDir, fnm)
+}
+
+// InitManifest records the full current configuration in Manifest, along
+// with the start time and build info, and saves it.
+func (ss *Sim) InitManifest(note string) {
+	mf := &ss.Manifest
+	mf.Args = os.Args
+	mf.Flags = make(map[string]string)
+	flag.VisitAll(func(f *flag.Flag) {
+		mf.Flags[f.Name] = f.Value.String()
+	})
+	mf.Note = note
+	mf.Tag = ss.Tag
+	mf.ParamSet = ss.ParamSet
+	mf.Arch = ss.ArchName()
+	mf.RndSeed = ss.RndSeed
+	mf.MaxRuns = ss.MaxRuns
+	mf.MaxEpcs = ss.MaxEpcs
+	mf.MaxTrls = ss.MaxTrls
+	mf.NZeroStop = ss.NZeroStop
+	mf.MaxMins = ss.MaxMins
+	mf.TstPlateau = ss.TstPlateau
+	mf.TstPlateauTol = ss.TstPlateauTol
+	mf.TargCosDiff = ss.TargCosDiff
+	mf.Curric = ss.Curric
+	mf.V1Bank = ss.TrainEnv.Bank.N
+	mf.Prefetch = ss.TrainEnv.Prefetch.Workers
+	mf.Cont = ss.Cont
+	mf.PNovel = ss.PNovel
+	mf.PNovelSched = ss.PNovelSched
+	mf.LearnPrjns = ss.LearnPrjns
+	mf.Learning = ss.LearningPrjns()
+	mf.Frozen = ss.FrozenPrjns()
+	mf.LEDSet = LEDSet
+	mf.TrainEnv = NewManifestEnv(&ss.TrainEnv)
+	mf.NovelEnv = NewManifestEnv(&ss.NovelTrainEnv)
+	mf.TestEnv = NewManifestEnv(&ss.TestEnv)
+	mf.Vis = NewManifestVis(&ss.TrainEnv)
+	mf.Layers = nil
+	for li := 0; li < ss.Net.NLayers(); li++ {
+		ly := ss.Net.Layer(li)
+		ml := ManifestLayer{Name: ly.Name(), Type: ly.Type().String(), Shape: ly.Shape().Shp}
+		for _, pj := range *ly.RecvPrjns() {
+			ml.Prjns = append(ml.Prjns, fmt.Sprintf("%s: %s", pj.Name(), pj.Pattern().Name()))
+		}
+		mf.Layers = append(mf.Layers, ml)
+	}
+	mf.V1V4Prjn = ss.V1V4Prjn
+	mf.V1ITPrjn = ss.V1ITPrjn
+	mf.Params = ss.EffectiveParams()
+	mf.StartTime = time.Now()
+	mf.Host, _ = os.Hostname()
+	mf.GoVersion = runtime.Version()
+	mf.GOOS = runtime.GOOS
+	mf.GOARCH = runtime.GOARCH
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		mf.MainModule = bi.Main.Path + "@" + bi.Main.Version
+		mf.Deps = nil
+		for _, dp := range bi.Deps {
+			mf.Deps = append(mf.Deps, dp.Path+"@"+dp.Version)
+		}
+	}
+	ss.SaveManifest()
+}
+
+// EndManifest records the end time in the Manifest and saves it, if
+// InitManifest was called
+func (ss *Sim) EndManifest() {
+	if ss.Manifest.StartTime.IsZero() {
+		return
+	}
+	ss.Manifest.EndTime = time.Now()
+	ss.SaveManifest()
+}
+
+// SaveManifest saves the Manifest as JSON to manifest.json in the RunDir
+func (ss *Sim) SaveManifest() error {
+	b, err := json.MarshalIndent(&ss.Manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ss.OutFileName("manifest.json"), b, 0644)
+}
+
+// NewManifestEnv returns the ManifestEnv record for given environment
+func NewManifestEnv(ev *LEDEnv) ManifestEnv {
+	return ManifestEnv{MinLED: ev.MinLED, MaxLED: ev.MaxLED, Trials: ev.Trial.Max, XFormRand: ev.XFormRand}
+}
+
+// NewManifestVis returns the ManifestVis record for given environment
+func NewManifestVis(ev *LEDEnv) ManifestVis {
+	vi := &ev.Vis
+	mv := ManifestVis{V1sGabor: vi.V1sGabor, V1sGeom: vi.V1sGeom, V1sNeighInhib: vi.V1sNeighInhib, V1sKWTA: vi.V1sKWTA, ImgSize: vi.ImgSize, NScales: vi.NScales, LEDWidth: ev.Draw.Width, LEDSize: ev.Draw.Size, LEDImgSize: ev.Draw.ImgSize}
+	for i := range vi.Coarse {
+		mv.Coarse = append(mv.Coarse, vi.Coarse[i].V1sGabor)
+	}
+	return mv
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/leabra/leabra"
+)
+
+// NpyArray is one named array to be saved in numpy .npy format.
+// Data must be a []float32, []float64, []int64 or []uint8 slice.
+type NpyArray struct {
+	Name  string      `desc:"name of array -- file name within .npz"`
+	Shape []int       `desc:"shape of array"`
+	Data  interface{} `desc:"array data in row-major order"`
+}
+
+// NpyDescr returns the numpy dtype descriptor for given data slice
+func NpyDescr(data interface{}) (string, error) {
+	switch data.(type) {
+	case []float32:
+		return "<f4", nil
+	case []float64:
+		return "<f8", nil
+	case []int64:
+		return "<i8", nil
+	case []uint8:
+		return "|u1", nil
+	}
+	return "", fmt.Errorf("npy: unsupported data type: %T", data)
+}
+
+// WriteNpy writes given array to w in numpy .npy format, version 1.0
+func WriteNpy(w io.Writer, ar *NpyArray) error {
+	descr, err := NpyDescr(ar.Data)
+	if err != nil {
+		return err
+	}
+	dims := make([]string, len(ar.Shape))
+	for i, d := range ar.Shape {
+		dims[i] = strconv.Itoa(d)
+	}
+	shp := "(" + strings.Join(dims, ", ")
+	if len(dims) == 1 {
+		shp += ","
+	}
+	shp += ")"
+	hdr := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': %s, }", descr, shp)
+	// magic (6) + version (2) + header len (2) + header + newline must be multiple of 64
+	pad := 64 - (10+len(hdr)+1)%64
+	if pad == 64 {
+		pad = 0
+	}
+	hdr += strings.Repeat(" ", pad) + "\n"
+	_, err = w.Write([]byte("\x93NUMPY\x01\x00"))
+	if err != nil {
+		return err
+	}
+	err = binary.Write(w, binary.LittleEndian, uint16(len(hdr)))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write([]byte(hdr))
+	if err != nil {
+		return err
+	}
+	return binary.Write(w, binary.LittleEndian, ar.Data)
+}
+
+// SaveNpy saves given array to a .npy file
+func SaveNpy(fnm string, ar *NpyArray) error {
+	f, err := os.Create(fnm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return WriteNpy(f, ar)
+}
+
+// SaveNpz saves given arrays to a .npz file, which numpy.load opens as a
+// dictionary of arrays by Name.
+func SaveNpz(fnm string, ars []*NpyArray) error {
+	f, err := os.Create(fnm)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, ar := range ars {
+		w, err := zw.Create(ar.Name + ".npy")
+		if err != nil {
+			return err
+		}
+		err = WriteNpy(w, ar)
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// TableNpyArrays returns an NpyArray for each numeric column in given table,
+// with shape [rows, cell shape...].  String columns are skipped.
+func TableNpyArrays(dt *etable.Table) []*NpyArray {
+	var ars []*NpyArray
+	for ci, cl := range dt.Cols {
+		shp := append([]int{dt.Rows}, cl.Shapes()[1:]...)
+		ar := &NpyArray{Name: dt.ColNames[ci], Shape: shp}
+		switch tsr := cl.(type) {
+		case *etensor.Float32:
+			ar.Data = tsr.Values
+		case *etensor.Float64:
+			ar.Data = tsr.Values
+		case *etensor.Int64:
+			ar.Data = tsr.Values
+		default:
+			continue
+		}
+		ars = append(ars, ar)
+	}
+	return ars
+}
+
+// PrjnWtsNpyArrays returns an NpyArray of the weights of each projection in
+// the network, named by projection, with shape [recv layer shape..., send
+// layer shape...], and a corresponding uint8 _mask array that is 1 where a
+// connection exists (weights are 0 where it does not).  Also returns a map
+// of shape metadata for each projection.
+func (ss *Sim) PrjnWtsNpyArrays() ([]*NpyArray, map[string]interface{}) {
+	var ars []*NpyArray
+	meta := make(map[string]interface{})
+	for li := 0; li < ss.Net.NLayers(); li++ {
+		rly := ss.Net.Layer(li)
+		for _, epj := range *rly.RecvPrjns() {
+			pj := epj.(leabra.LeabraPrjn).AsLeabra()
+			sly := pj.Send
+			rshp := rly.Shape().Shp
+			sshp := sly.Shape().Shp
+			nr := rly.Shape().Len()
+			ns := sly.Shape().Len()
+			wts := make([]float32, nr*ns)
+			mask := make([]uint8, nr*ns)
+			for si := 0; si < ns; si++ {
+				nc := int(pj.SConN[si])
+				st := int(pj.SConIdxSt[si])
+				for ci := 0; ci < nc; ci++ {
+					ri := int(pj.SConIdx[st+ci])
+					wts[ri*ns+si] = pj.Syns[st+ci].Wt
+					mask[ri*ns+si] = 1
+				}
+			}
+			shp := append(append([]int{}, rshp...), sshp...)
+			nm := pj.Name()
+			ars = append(ars, &NpyArray{Name: nm, Shape: shp, Data: wts})
+			ars = append(ars, &NpyArray{Name: nm + "_mask", Shape: shp, Data: mask})
+			meta[nm] = map[string]interface{}{
+				"Recv":      rly.Name(),
+				"RecvShape": rshp,
+				"Send":      sly.Name(),
+				"SendShape": sshp,
+				"Pattern":   pj.Pattern().Name(),
+				"Class":     pj.Class(),
+			}
+		}
+	}
+	return ars, meta
+}
+
+// SaveWtsNpz saves the weights of all projections to given .npz file (see
+// PrjnWtsNpyArrays), and their shape metadata to the same name with .json
+// extension.
+func (ss *Sim) SaveWtsNpz(fnm string) error {
+	ars, meta := ss.PrjnWtsNpyArrays()
+	err := SaveNpz(fnm, ars)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(fnm, ".npz")+".json", b, 0644)
+}
+
+// SaveActsNpz saves the TstActLog per-trial V1 input and layer ActM
+// activations recorded during TestAll to given .npz file.
+func (ss *Sim) SaveActsNpz(fnm string) error {
+	return SaveNpz(fnm, TableNpyArrays(ss.TstActLog))
+}
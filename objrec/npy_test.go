@@ -0,0 +1,116 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkNpy checks the .npy format of given data written by WriteNpy, and
+// returns the payload after the header.
+func checkNpy(t *testing.T, b []byte, descr, shape string) []byte {
+	t.Helper()
+	if len(b) < 10 || string(b[:8]) != "\x93NUMPY\x01\x00" {
+		t.Fatalf("bad magic / version: %q", b[:8])
+	}
+	hlen := int(binary.LittleEndian.Uint16(b[8:10]))
+	if (10+hlen)%64 != 0 {
+		t.Errorf("header end %d not aligned to 64 bytes", 10+hlen)
+	}
+	if len(b) < 10+hlen {
+		t.Fatalf("header length %d longer than data %d", hlen, len(b)-10)
+	}
+	hdr := string(b[10 : 10+hlen])
+	if !strings.HasSuffix(hdr, "\n") {
+		t.Errorf("header does not end in newline: %q", hdr)
+	}
+	want := "{'descr': '" + descr + "', 'fortran_order': False, 'shape': " + shape + ", }"
+	if strings.TrimRight(hdr, " \n") != want {
+		t.Errorf("header = %q, want %q", strings.TrimRight(hdr, " \n"), want)
+	}
+	return b[10+hlen:]
+}
+
+func TestWriteNpy(t *testing.T) {
+	var buf bytes.Buffer
+	f32 := []float32{1, -2.5, 3.25, 0, 5, 6}
+	err := WriteNpy(&buf, &NpyArray{Name: "f", Shape: []int{2, 3}, Data: f32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay := checkNpy(t, buf.Bytes(), "<f4", "(2, 3)")
+	if len(pay) != 4*len(f32) {
+		t.Fatalf("float32 payload is %d bytes, want %d", len(pay), 4*len(f32))
+	}
+	for i, v := range f32 {
+		if got := math.Float32frombits(binary.LittleEndian.Uint32(pay[4*i:])); got != v {
+			t.Errorf("float32 [%d] = %g, want %g", i, got, v)
+		}
+	}
+
+	buf.Reset()
+	u8 := []uint8{0, 1, 255, 7, 1}
+	err = WriteNpy(&buf, &NpyArray{Name: "u", Shape: []int{5}, Data: u8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pay = checkNpy(t, buf.Bytes(), "|u1", "(5,)")
+	if !bytes.Equal(pay, u8) {
+		t.Errorf("uint8 payload = %v, want %v", pay, u8)
+	}
+
+	err = WriteNpy(&buf, &NpyArray{Name: "s", Shape: []int{1}, Data: []string{"a"}})
+	if err == nil {
+		t.Errorf("WriteNpy of []string: no error")
+	}
+}
+
+func TestSaveNpz(t *testing.T) {
+	fnm := filepath.Join(t.TempDir(), "test.npz")
+	ars := []*NpyArray{
+		{Name: "wts", Shape: []int{2, 2}, Data: []float32{0.5, 1, 1.5, 2}},
+		{Name: "wts_mask", Shape: []int{2, 2}, Data: []uint8{1, 0, 1, 1}},
+	}
+	err := SaveNpz(fnm, ars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.OpenReader(fnm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if len(zr.File) != len(ars) {
+		t.Fatalf("npz has %d files, want %d", len(zr.File), len(ars))
+	}
+	descrs := []string{"<f4", "|u1"}
+	for i, zf := range zr.File {
+		if zf.Name != ars[i].Name+".npy" {
+			t.Errorf("npz file %d = %s, want %s.npy", i, zf.Name, ars[i].Name)
+		}
+		r, err := zf.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var want bytes.Buffer
+		binary.Write(&want, binary.LittleEndian, ars[i].Data)
+		pay := checkNpy(t, b, descrs[i], "(2, 2)")
+		if !bytes.Equal(pay, want.Bytes()) {
+			t.Errorf("%s payload = %v, want %v", zf.Name, pay, want.Bytes())
+		}
+	}
+}
@@ -11,7 +11,6 @@ input images.
 package main
 
 import (
-	"bytes"
 	"flag"
 	"fmt"
 	"log"
@@ -92,6 +91,17 @@ var ParamSets = params.Sets{
 					"Layer.Inhib.Pool.On":     "true", // clamped, so not relevant, but just in case
 					"Layer.Inhib.ActAvg.Init": "0.1",
 				}},
+			{Sel: ".V1Coarse", Desc: "coarser V1 scale input layers (-v1scales): same as V1",
+				Params: params.Params{
+					"Layer.Inhib.Pool.On":     "true",
+					"Layer.Inhib.ActAvg.Init": "0.1",
+				}},
+			{Sel: ".V1Scale", Desc: "prjns from the coarser V1 scales: weaker than from the standard V1, which has the detail",
+				Params: params.Params{
+					"Prjn.WtScale.Rel": "0.5",
+					"Prjn.WtInit.Mean": "0.5",
+					"Prjn.WtInit.Var":  "0.25",
+				}},
 			{Sel: "#V4", Desc: "pool inhib, sparse activity",
 				Params: params.Params{
 					"Layer.Inhib.Pool.On":     "true", // needs pool-level
@@ -140,12 +150,30 @@ type Sim struct {
 	// [view: no-inline] testing epoch-level log data
 	TstEpcLog *etable.Table `view:"no-inline" desc:"testing epoch-level log data"`
 
+	// [view: no-inline] history of overall testing accuracy over the run, one row per TestAll
+	TstHistLog *etable.Table `view:"no-inline" desc:"history of overall testing accuracy over the run, one row per TestAll"`
+
 	// [view: no-inline] testing trial-level log data
 	TstTrlLog *etable.Table `view:"no-inline" desc:"testing trial-level log data"`
 
+	// [view: no-inline] testing trial-level V1 input and layer activations -- only recorded if ActLog is on
+	TstActLog *etable.Table `view:"no-inline" desc:"testing trial-level V1 input and layer activations -- only recorded if ActLog is on"`
+
+	// [view: no-inline] testing cycle-level log data for all the trials of the current test epoch -- only recorded if CycLog is on
+	TstCycLog *etable.Table `view:"no-inline" desc:"testing cycle-level log data for all the trials of the current test epoch -- only recorded if CycLog is on"`
+
 	// [view: no-inline] activation-based receptive fields
 	ActRFs actrf.RFs `view:"no-inline" desc:"activation-based receptive fields"`
 
+	// [view: no-inline] top-down generated V4, IT patterns for each clamped Output unit, and their projections into image space through ActRFs
+	GenLog *etable.Table `view:"no-inline" desc:"top-down generated V4, IT patterns for each clamped Output unit, and their projections into image space through ActRFs"`
+
+	// [view: no-inline] accuracy on originally trained vs. novel objects over novel training
+	InterfLog *etable.Table `view:"no-inline" desc:"accuracy on originally trained vs. novel objects over novel training"`
+
+	// [view: no-inline] test accuracy on each class after each stage of continual class-incremental learning
+	ContLog *etable.Table `view:"no-inline" desc:"test accuracy on each class after each stage of continual class-incremental learning"`
+
 	// [view: no-inline] summary log of each run
 	RunLog *etable.Table `view:"no-inline" desc:"summary log of each run"`
 
@@ -161,11 +189,23 @@ type Sim struct {
 	// extra tag string to add to any file names output from sim (e.g., weights files, log files, params for run)
 	Tag string `desc:"extra tag string to add to any file names output from sim (e.g., weights files, log files, params for run)"`
 
+	// label for the architecture / topography variant of the network, recorded in the RunLog to separate conditions -- see ArchName
+	Arch string `desc:"label for the architecture / topography variant of the network, recorded in the RunLog to separate conditions -- see ArchName"`
+
 	// [view: projection from V1 to V4 which is tiled 4x4 skip 2 with topo scale values]
 	V1V4Prjn *prjn.PoolTile `view:"projection from V1 to V4 which is tiled 4x4 skip 2 with topo scale values"`
 
 	V1ITPrjn *prjn.PoolTile
 
+	// number of V1 filtering scales, each with its own V1 input layer -- 1 = standard scale only -- set with -v1scales, as it changes the network
+	V1Scales int `min:"1" inactive:"+" desc:"number of V1 filtering scales, each with its own V1 input layer -- 1 = standard scale only -- set with -v1scales, as it changes the network"`
+
+	// number of gabor filter orientations in V1, e.g., 4, 6 or 8 -- set with -v1angles, as it changes the network
+	V1Angles int `min:"2" inactive:"+" desc:"number of gabor filter orientations in V1, e.g., 4, 6 or 8 -- set with -v1angles, as it changes the network"`
+
+	// layers that the coarser V1 scale layers project to, space-separated, e.g., 'IT' or 'V4 IT'
+	V1ScalesTo string `desc:"layers that the coarser V1 scale layers project to, space-separated, e.g., 'IT' or 'V4 IT'"`
+
 	// maximum number of model runs to perform
 	MaxRuns int `desc:"maximum number of model runs to perform"`
 
@@ -178,12 +218,60 @@ type Sim struct {
 	// if a positive number, training will stop after this many epochs with zero SSE
 	NZeroStop int `desc:"if a positive number, training will stop after this many epochs with zero SSE"`
 
+	// if a positive number, training will stop after this many minutes of wall-clock time in a run
+	MaxMins float64 `desc:"if a positive number, training will stop after this many minutes of wall-clock time in a run"`
+
+	// if a positive number, training will stop when testing accuracy has not improved by more than TstPlateauTol over this many epochs -- requires TestInterval > 0
+	TstPlateau int `desc:"if a positive number, training will stop when testing accuracy has not improved by more than TstPlateauTol over this many epochs -- requires TestInterval > 0"`
+
+	// minimum improvement in testing PctCor over TstPlateau epochs for training to continue
+	TstPlateauTol float64 `desc:"minimum improvement in testing PctCor over TstPlateau epochs for training to continue"`
+
+	// if a positive number, training will stop when the epoch average CosDiff reaches this value
+	TargCosDiff float64 `desc:"if a positive number, training will stop when the epoch average CosDiff reaches this value"`
+
+	// how often to run through all the test patterns during training, in terms of training epochs -- can use 0 or -1 for no testing
+	TestInterval int `desc:"how often to run through all the test patterns during training, in terms of training epochs -- can use 0 or -1 for no testing"`
+
 	// Training environment -- LED training
 	TrainEnv LEDEnv `desc:"Training environment -- LED training"`
 
+	// curriculum over the TrainEnv random transform ranges, widening from small transforms over training
+	Curric Curric `desc:"curriculum over the TrainEnv random transform ranges, widening from small transforms over training"`
+
+	// trained weights used by OpenTrainedWts and TrainNovel: name of embedded weights (objrec_train1, objrec_train2) or path to a weights file
+	TrainedWts string `desc:"trained weights used by OpenTrainedWts and TrainNovel: name of embedded weights (objrec_train1, objrec_train2) or path to a weights file"`
+
+	// if true, each new run starts from the TrainedWts instead of random initial weights
+	RunFromTrained bool `desc:"if true, each new run starts from the TrainedWts instead of random initial weights"`
+
 	// proportion of novel training items to use -- set this to 0.5 after initial training
 	PNovel float32 `desc:"proportion of novel training items to use -- set this to 0.5 after initial training"`
 
+	// schedule of PNovel over epochs of novel training, as space-separated epoch:pnovel entries that each apply from that epoch on, e.g., '0:0.5 10:0.25' -- PNovel is 0.5 throughout if empty
+	PNovelSched string `desc:"schedule of PNovel over epochs of novel training, as space-separated epoch:pnovel entries that each apply from that epoch on, e.g., '0:0.5 10:0.25' -- PNovel is 0.5 throughout if empty"`
+
+	// [view: -] the PNovelSched parsed into steps sorted by epoch, by TrainNovel
+	PNovelSteps []PNovelStep `view:"-" desc:"the PNovelSched parsed into steps sorted by epoch, by TrainNovel"`
+
+	// number of held-out test trials on each of the trained and novel objects, after each epoch of novel training, for the InterfLog -- 0 = none
+	InterfTrls int `desc:"number of held-out test trials on each of the trained and novel objects, after each epoch of novel training, for the InterfLog -- 0 = none"`
+
+	// if set, only these projections learn, and all others are frozen -- space-separated list of #PrjnName, .Class, or receiving layer name entries, e.g., 'IT Output' -- if empty, TrainNovel uses the NovelLearn params
+	LearnPrjns string `desc:"if set, only these projections learn, and all others are frozen -- space-separated list of #PrjnName, .Class, or receiving layer name entries, e.g., 'IT Output' -- if empty, TrainNovel uses the NovelLearn params"`
+
+	// [view: -] original LrateInit of each projection, from before FreezePrjns set it to 0 -- restored for the LearnPrjns on each freeze
+	LrateInits map[string]float32 `view:"-" desc:"original LrateInit of each projection, from before FreezePrjns set it to 0 -- restored for the LearnPrjns on each freeze"`
+
+	// parameters for the continual class-incremental learning protocol run by TrainContinual
+	Cont ContParams `desc:"parameters for the continual class-incremental learning protocol run by TrainContinual"`
+
+	// [view: -] if true, LrateSched does not change the learning rate -- set while TrainContinual runs
+	NoLrateSched bool `view:"-" desc:"if true, LrateSched does not change the learning rate -- set while TrainContinual runs"`
+
+	// true if training novel items, per TrainNovel -- each new run starts from TrainedWts
+	Novel bool `inactive:"+" desc:"true if training novel items, per TrainNovel -- each new run starts from TrainedWts"`
+
 	// Novel items training environment -- LED training
 	NovelTrainEnv LEDEnv `desc:"Novel items training environment -- LED training"`
 
@@ -208,6 +296,21 @@ type Sim struct {
 	// names of layers to compute activation rfields on
 	ActRFNms []string `desc:"names of layers to compute activation rfields on"`
 
+	// standard deviation of gaussian noise in V1 during top-down generation test -- 0 = V1 is empty
+	GenNoise float32 `def:"0" desc:"standard deviation of gaussian noise in V1 during top-down generation test -- 0 = V1 is empty"`
+
+	// if true, record V1 input and V4, IT, Output ActM activations for each testing trial into TstActLog
+	ActLog bool `desc:"if true, record V1 input and V4, IT, Output ActM activations for each testing trial into TstActLog"`
+
+	// if true, record cycle-by-cycle Output and IT activations during testing into TstCycLog -- slows testing
+	CycLog bool `desc:"if true, record cycle-by-cycle Output and IT activations during testing into TstCycLog -- slows testing"`
+
+	// reaction time threshold: the RT is the first cycle at which the correct Output unit activation reaches this value, or wins by RTMargin -- 0 = not used
+	RTThr float32 `def:"0.5" desc:"reaction time threshold: the RT is the first cycle at which the correct Output unit activation reaches this value, or wins by RTMargin -- 0 = not used"`
+
+	// reaction time margin: the RT is the first cycle at which the correct Output unit activation exceeds that of all other Output units by this amount, or reaches RTThr -- 0 = not used
+	RTMargin float32 `def:"0" desc:"reaction time margin: the RT is the first cycle at which the correct Output unit activation exceeds that of all other Output units by this amount, or reaches RTThr -- 0 = not used"`
+
 	// 1 if trial was error, 0 if correct -- based on SSE = 0 (subject to .5 unit-wise tolerance)
 	TrlErr float64 `inactive:"+" desc:"1 if trial was error, 0 if correct -- based on SSE = 0 (subject to .5 unit-wise tolerance)"`
 
@@ -220,6 +323,9 @@ type Sim struct {
 	// current trial's cosine difference
 	TrlCosDiff float64 `inactive:"+" desc:"current trial's cosine difference"`
 
+	// current test trial's reaction time: cycle at which the correct Output unit met the RTThr or RTMargin criterion -- -1 if never
+	TrlRT float64 `inactive:"+" desc:"current test trial's reaction time: cycle at which the correct Output unit met the RTThr or RTMargin criterion -- -1 if never"`
+
 	// last epoch's total sum squared error
 	EpcSSE float64 `inactive:"+" desc:"last epoch's total sum squared error"`
 
@@ -280,15 +386,33 @@ type Sim struct {
 	// [view: -] the test-trial plot
 	TstTrlPlot *eplot.Plot2D `view:"-" desc:"the test-trial plot"`
 
+	// [view: -] the test-cycle plot
+	TstCycPlot *eplot.Plot2D `view:"-" desc:"the test-cycle plot"`
+
 	// [view: -] the run plot
 	RunPlot *eplot.Plot2D `view:"-" desc:"the run plot"`
 
+	// [view: -] the novel training interference plot
+	InterfPlot *eplot.Plot2D `view:"-" desc:"the novel training interference plot"`
+
+	// [view: -] the top-down generation table view
+	GenView *etview.TableView `view:"-" desc:"the top-down generation table view"`
+
 	// [view: -] log file
 	TrnEpcFile *os.File `view:"-" desc:"log file"`
 
 	// [view: -] log file
 	RunFile *os.File `view:"-" desc:"log file"`
 
+	// [view: -] log file
+	TstTrlFile *os.File `view:"-" desc:"log file"`
+
+	// [view: -] log file
+	TstEpcFile *os.File `view:"-" desc:"log file"`
+
+	// [view: -] log file
+	TstCycFile *os.File `view:"-" desc:"log file"`
+
 	// [view: -] for holding layer values
 	ValsTsrs map[string]*etensor.Float32 `view:"-" desc:"for holding layer values"`
 
@@ -307,12 +431,33 @@ type Sim struct {
 	// [view: -] flag to stop running
 	StopNow bool `view:"-" desc:"flag to stop running"`
 
+	// [view: -] control server, if serving -- the sim publishes snapshots of its state and logs to it
+	Server *SimServer `view:"-" desc:"control server, if serving -- the sim publishes snapshots of its state and logs to it"`
+
+	// [view: -] true during TestTrial and TestItem, whose correct Output is the TestEnv.CurLED, so that AlphaCyc records the CycleStats -- other testing (gen, classify, interference tests) leaves the RT and TstCycLog alone
+	CycStats bool `view:"-" desc:"true during TestTrial and TestItem, whose correct Output is the TestEnv.CurLED, so that AlphaCyc records the CycleStats -- other testing (gen, classify, interference tests) leaves the RT and TstCycLog alone"`
+
+	// [view: -] time when the current run started, for MaxMins
+	RunStartTime time.Time `view:"-" desc:"time when the current run started, for MaxMins"`
+
+	// [view: -] name of the stopping rule that ended the last run (see CheckStop)
+	StopRule string `view:"-" desc:"name of the stopping rule that ended the last run (see CheckStop)"`
+
+	// [view: -] true if a command-line run was stopped by an interrupt signal
+	Interrupted bool `view:"-" desc:"true if a command-line run was stopped by an interrupt signal"`
+
 	// [view: -] flag to initialize NewRun if last one finished
 	NeedsNewRun bool `view:"-" desc:"flag to initialize NewRun if last one finished"`
 
 	// [view: -] the current random seed
 	RndSeed int64 `view:"-" desc:"the current random seed"`
 
+	// [view: -] for command-line run only, directory where all output files are saved -- if empty, current directory
+	RunDir string `view:"-" desc:"for command-line run only, directory where all output files are saved -- if empty, current directory"`
+
+	// [view: -] for command-line run only, record of full configuration saved in the RunDir
+	Manifest RunManifest `view:"-" desc:"for command-line run only, record of full configuration saved in the RunDir"`
+
 	// [view: -] timer for last epoch
 	LastEpcTime time.Time `view:"-" desc:"timer for last epoch"`
 }
@@ -329,9 +474,15 @@ func (ss *Sim) New() {
 	ss.Net = &leabra.Network{}
 	ss.TrnEpcLog = &etable.Table{}
 	ss.TstEpcLog = &etable.Table{}
+	ss.TstHistLog = &etable.Table{}
 	ss.TstTrlLog = &etable.Table{}
+	ss.TstActLog = &etable.Table{}
+	ss.TstCycLog = &etable.Table{}
+	ss.GenLog = &etable.Table{}
 	ss.RunLog = &etable.Table{}
 	ss.RunStats = &etable.Table{}
+	ss.InterfLog = &etable.Table{}
+	ss.ContLog = &etable.Table{}
 	ss.Params = ParamSets
 	ss.V1V4Prjn = prjn.NewPoolTile()
 	ss.V1V4Prjn.Size.Set(8, 10)
@@ -343,6 +494,9 @@ func (ss *Sim) New() {
 	ss.V1ITPrjn.Skip.Set(1, 1)
 	ss.V1ITPrjn.Start.Set(0, 0)
 	ss.V1ITPrjn.TopoRange.Min = 0.8 // note: none of these make a very big diff
+	ss.V1Scales = 1
+	ss.V1Angles = 4
+	ss.V1ScalesTo = "IT"
 	// but using a symmetric scale range .8 - 1.2 seems like it might be good -- otherwise
 	// weights are systematicaly smaller.
 	// ss.V1V4Prjn.GaussFull.DefNoWrap()
@@ -353,7 +507,14 @@ func (ss *Sim) New() {
 	ss.TestUpdt = leabra.Quarter
 	ss.LayStatNms = []string{"V1", "Output"}
 	ss.ActRFNms = []string{"V4:Image", "V4:Output", "IT:Image", "IT:Output"}
+	ss.RTThr = 0.5
+	ss.RTMargin = 0
+	ss.TestInterval = -1
 	ss.PNovel = 0
+	ss.InterfTrls = 100
+	ss.TrainedWts = "objrec_train1"
+	ss.Curric.Defaults()
+	ss.Cont.Defaults()
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////
@@ -365,8 +526,14 @@ func (ss *Sim) Config() {
 	ss.ConfigNet(ss.Net)
 	ss.ConfigTrnEpcLog(ss.TrnEpcLog)
 	ss.ConfigTstEpcLog(ss.TstEpcLog)
+	ss.ConfigTstHistLog(ss.TstHistLog)
 	ss.ConfigTstTrlLog(ss.TstTrlLog)
+	ss.ConfigTstActLog(ss.TstActLog)
+	ss.ConfigTstCycLog(ss.TstCycLog)
 	ss.ConfigRunLog(ss.RunLog)
+	ss.ConfigGenLog(ss.GenLog)
+	ss.ConfigInterfLog(ss.InterfLog)
+	ss.ConfigContLog(ss.ContLog)
 }
 
 func (ss *Sim) ConfigEnv() {
@@ -389,6 +556,7 @@ func (ss *Sim) ConfigEnv() {
 	ss.TrainEnv.Validate()
 	ss.TrainEnv.Run.Max = ss.MaxRuns // note: we are not setting epoch max -- do that manually
 	ss.TrainEnv.Trial.Max = ss.MaxTrls
+	ss.Curric.Full = ss.TrainEnv.XFormRand
 
 	ss.NovelTrainEnv.Nm = "NovelTrainEnv"
 	ss.NovelTrainEnv.Dsc = "novel items training params and state"
@@ -411,6 +579,11 @@ func (ss *Sim) ConfigEnv() {
 	ss.TestEnv.Trial.Max = 500 // 1000 is too long!
 	ss.TestEnv.Validate()
 
+	for _, ev := range []*LEDEnv{&ss.TrainEnv, &ss.NovelTrainEnv, &ss.TestEnv} {
+		ev.Vis.SetScales(ss.V1Scales)
+		ev.Vis.SetAngles(ss.V1Angles)
+	}
+
 	ss.TrainEnv.Init(0)
 	ss.NovelTrainEnv.Init(0)
 	ss.TestEnv.Init(0)
@@ -418,7 +591,8 @@ func (ss *Sim) ConfigEnv() {
 
 func (ss *Sim) ConfigNet(net *leabra.Network) {
 	net.InitName(net, "Objrec")
-	v1 := net.AddLayer4D("V1", 10, 10, 5, 4, emer.Input)
+	shps := ss.TrainEnv.Vis.Shapes()
+	v1 := net.AddLayer4D("V1", shps[0][0], shps[0][1], shps[0][2], shps[0][3], emer.Input)
 	v4 := net.AddLayer4D("V4", 5, 5, 7, 7, emer.Hidden)
 	it := net.AddLayer4D("IT", 2, 2, 5, 5, emer.Hidden)
 	out := net.AddLayer2D("Output", 4, 5, emer.Target)
@@ -431,6 +605,8 @@ func (ss *Sim) ConfigNet(net *leabra.Network) {
 	it.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "V4", YAlign: relpos.Front, Space: 2})
 	out.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: "IT", YAlign: relpos.Front, Space: 2})
 
+	ss.ConfigV1Scales(net, shps)
+
 	v4IT.SetClass("NovLearn")
 	itOut.SetClass("NovLearn")
 	outIT.SetClass("NovLearn")
@@ -449,6 +625,52 @@ func (ss *Sim) ConfigNet(net *leabra.Network) {
 	ss.InitWts(net)
 }
 
+// ConfigV1Scales adds a V1ScaleN input layer for each of the coarser V1
+// scales, with given shapes, projecting to the V1ScalesTo layers.  The
+// layers have class V1Coarse and the prjns class V1Scale, for the params.
+func (ss *Sim) ConfigV1Scales(net *leabra.Network, shps [][]int) {
+	for sc := 1; sc < len(shps); sc++ {
+		shp := shps[sc]
+		ly := net.AddLayer4D(V1ScaleLayName(sc), shp[0], shp[1], shp[2], shp[3], emer.Input)
+		ly.SetClass("V1Coarse")
+		ly.SetRelPos(relpos.Rel{Rel: relpos.RightOf, Other: V1ScaleLayName(sc - 1), YAlign: relpos.Front, Space: 2})
+		for _, rnm := range strings.Fields(ss.V1ScalesTo) {
+			recv := net.LayerByName(rnm)
+			if recv == nil {
+				log.Printf("V1ScalesTo: layer %s not found\n", rnm)
+				continue
+			}
+			pj := net.ConnectLayers(ly, recv, V1ScalePrjn(ly, recv), emer.Forward)
+			pj.SetClass("V1Scale")
+		}
+	}
+}
+
+// V1ScalePrjn returns the projection pattern from a coarse V1 scale layer to
+// given layer: topographic 3x3 neighborhoods of pools if the layers have the
+// same grid of pools, and full otherwise.
+func V1ScalePrjn(send, recv emer.Layer) prjn.Pattern {
+	ssh, rsh := send.Shape(), recv.Shape()
+	if rsh.NumDims() == 4 && ssh.Dim(0) == rsh.Dim(0) && ssh.Dim(1) == rsh.Dim(1) {
+		pt := prjn.NewPoolTile()
+		pt.Size.Set(3, 3)
+		pt.Skip.Set(1, 1)
+		pt.Start.Set(-1, -1)
+		pt.TopoRange.Min = 0.8
+		return pt
+	}
+	return prjn.NewFull()
+}
+
+// V1LayNames returns the names of the V1 input layers, one per V1 scale
+func (ss *Sim) V1LayNames() []string {
+	nms := make([]string, ss.TrainEnv.Vis.NScales)
+	for sc := range nms {
+		nms[sc] = V1ScaleLayName(sc)
+	}
+	return nms
+}
+
 func (ss *Sim) InitWts(net *leabra.Network) {
 	net.InitTopoScales() //  sets all wt scales
 	net.InitWts()
@@ -463,7 +685,14 @@ func (ss *Sim) InitWts(net *leabra.Network) {
 func (ss *Sim) Init() {
 	rand.Seed(ss.RndSeed)
 	ss.StopNow = false
+	ss.Novel = false
 	ss.SetParams("", false) // all sheets
+	if ss.LearnPrjns != "" {
+		err := ss.FreezePrjns(false)
+		if err != nil {
+			log.Println(err)
+		}
+	}
 	ss.NewRun()
 	ss.UpdateView(true, -1)
 	if ss.NetView != nil && ss.NetView.IsVisible() {
@@ -513,9 +742,16 @@ func (ss *Sim) AlphaCyc(train bool) {
 
 	ss.Net.AlphaCycInit(train)
 	ss.Time.AlphaCycStart()
+	cycStats := !train && ss.CycStats
+	if cycStats {
+		ss.TrlRT = -1
+	}
 	for qtr := 0; qtr < 4; qtr++ {
 		for cyc := 0; cyc < ss.Time.CycPerQtr; cyc++ {
 			ss.Net.Cycle(&ss.Time)
+			if cycStats {
+				ss.CycleStats(ss.Time.Cycle)
+			}
 			ss.Time.CycleInc()
 			if ss.ViewOn {
 				switch viewUpdt {
@@ -566,7 +802,7 @@ func (ss *Sim) ApplyInputs(en env.Env) {
 	ss.Net.InitExt() // clear any existing inputs -- not strictly necessary if always
 	// going to the same layers, but good practice and cheap anyway
 
-	lays := []string{"V1", "Output"}
+	lays := append(ss.V1LayNames(), "Output")
 	for _, lnm := range lays {
 		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
 		pats := en.State(ly.Nm)
@@ -591,13 +827,25 @@ func (ss *Sim) TrainTrial() {
 	// Key to query counters FIRST because current state is in NEXT epoch
 	// if epoch counter has changed
 	epc, _, chg := ss.TrainEnv.Counter(env.Epoch)
+	defer ss.Publish(chg) // logs only change at epoch boundaries
 	if chg {
 		ss.LogTrnEpc(ss.TrnEpcLog)
 		ss.LrateSched(epc)
+		ss.Curric.Epoch(epc, ss.EpcPctErr, &ss.TrainEnv.XFormRand)
+		if ss.Novel {
+			nepc := epc - NovelStartEpc
+			if ss.InterfTrls > 0 {
+				ss.LogInterf(ss.InterfLog, nepc)
+			}
+			ss.SetPNovel(nepc)
+		}
 		if ss.ViewOn && ss.TrainUpdt > leabra.AlphaCycle {
 			ss.UpdateView(true, -1)
 		}
-		if epc >= ss.MaxEpcs || (ss.NZeroStop > 0 && ss.NZero >= ss.NZeroStop) {
+		if ss.TestInterval > 0 && epc%ss.TestInterval == 0 {
+			ss.TestAll()
+		}
+		if ss.StopRule = ss.CheckStop(epc); ss.StopRule != "" {
 			// done with training..
 			ss.RunEnd()
 			if ss.TrainEnv.Run.Incr() { // we are done!
@@ -624,8 +872,13 @@ func (ss *Sim) TrainTrial() {
 	}
 }
 
-// RunEnd is called at the end of a run -- save weights, record final log, etc here
+// RunEnd is called at the end of a run -- save weights, record final log, etc here.
+// If testing during training is on, tests the final weights for the RunLog,
+// unless the last epoch was just tested.
 func (ss *Sim) RunEnd() {
+	if ss.TestInterval > 0 && !ss.TestedEpoch(ss.TrainEnv.Epoch.Prv) {
+		ss.TestAll()
+	}
 	ss.LogRun(ss.RunLog)
 	if ss.SaveWts {
 		fnm := ss.WeightsFileName()
@@ -640,12 +893,32 @@ func (ss *Sim) NewRun() {
 	run := ss.TrainEnv.Run.Cur
 	ss.TrainEnv.Init(run)
 	ss.TestEnv.Init(run)
+	ss.TrainEnv.Prefetch.Init(ss.RndSeed, run) // new stimulus sequences for the run
+	ss.NovelTrainEnv.Prefetch.Init(ss.RndSeed+1, run)
+	if ss.Curric.On {
+		ss.Curric.Init(&ss.TrainEnv.XFormRand)
+	}
 	ss.Time.Reset()
 	ss.InitWts(ss.Net)
+	if ss.RunFromTrained || ss.Novel {
+		ss.OpenTrainedWts()
+	}
 	ss.InitStats()
 	ss.TrnEpcLog.SetNumRows(0)
 	ss.TstEpcLog.SetNumRows(0)
+	ss.TstHistLog.SetNumRows(0)
+	ss.RunStartTime = time.Now()
+	ss.StopRule = ""
+	if ss.Novel {
+		ss.TrainEnv.Epoch.Cur = NovelStartEpc
+		ss.LrateSched(NovelStartEpc)
+		ss.SetPNovel(0)
+		if ss.InterfTrls > 0 {
+			ss.LogInterf(ss.InterfLog, 0) // baseline before novel training
+		}
+	}
 	ss.NeedsNewRun = false
+	ss.Publish(true) // logs were reset
 }
 
 // InitStats initializes all the statistics, especially important for the
@@ -693,6 +966,31 @@ func (ss *Sim) TrialStats(accum bool) (sse, avgsse, cosdiff float64) {
 	return
 }
 
+// CycleStats updates the reaction time for the current testing trial, based on
+// the activation of the correct Output unit relative to RTThr or RTMargin,
+// and records activations into TstCycLog if CycLog is on.  Only called
+// when CycStats is set, so the TestEnv.CurLED is the correct unit.
+func (ss *Sim) CycleStats(cyc int) {
+	out := ss.Net.LayerByName("Output").(leabra.LeabraLayer).AsLeabra()
+	cor := ss.TestEnv.CurLED
+	corAct := float32(0)
+	maxOth := float32(0)
+	for ni := range out.Neurons {
+		act := out.Neurons[ni].Act
+		if ni == cor {
+			corAct = act
+		} else if act > maxOth {
+			maxOth = act
+		}
+	}
+	if ss.TrlRT < 0 && ((ss.RTThr > 0 && corAct >= ss.RTThr) || (ss.RTMargin > 0 && corAct-maxOth >= ss.RTMargin)) {
+		ss.TrlRT = float64(cyc)
+	}
+	if ss.CycLog {
+		ss.LogTstCyc(ss.TstCycLog, cyc, corAct, maxOth)
+	}
+}
+
 // TrainEpoch runs training trials for remainder of this epoch
 func (ss *Sim) TrainEpoch() {
 	ss.StopNow = false
@@ -738,7 +1036,7 @@ func (ss *Sim) Stop() {
 
 // Stopped is called when a run method stops running -- updates the IsRunning flag and toolbar
 func (ss *Sim) Stopped() {
-	ss.IsRunning = false
+	ss.SetRunning(false)
 	if ss.Win != nil {
 		vp := ss.Win.WinViewport2D()
 		if ss.ToolBar != nil {
@@ -754,8 +1052,11 @@ func (ss *Sim) SaveWeights(filename gi.FileName) {
 	ss.Net.SaveWtsJSON(filename)
 }
 
-// LrateSched implements the learning rate schedule
+// LrateSched implements the learning rate schedule, unless NoLrateSched
 func (ss *Sim) LrateSched(epc int) {
+	if ss.NoLrateSched {
+		return
+	}
 	switch epc {
 	case 40:
 		ss.Net.LrateMult(0.5)
@@ -763,25 +1064,37 @@ func (ss *Sim) LrateSched(epc int) {
 	}
 }
 
-// OpenTrainedWts opens trained weights
+// OpenTrainedWts opens trained weights, from TrainedWts
 func (ss *Sim) OpenTrainedWts() {
-	ab, err := Asset("objrec_train1.wts") // embedded in executable
+	err := ss.OpenWts(ss.TrainedWts) // embedded in executable, or file
 	if err != nil {
 		log.Println(err)
 	}
-	ss.Net.ReadWtsJSON(bytes.NewBuffer(ab))
-	// ss.Net.OpenWtsJSON("objrec_train1.wts.gz")
 }
 
 // TrainNovel prepares network for training novel items: loads saved weights
 // changes PNovel -- just do Step Run after this.
+// Each new run starts again from the trained weights, and accuracy on the
+// trained vs. novel items is recorded in the InterfLog after every epoch.
+// Nothing is done if the PNovelSched is not valid.
 func (ss *Sim) TrainNovel() {
+	steps, err := ParsePNovelSched(ss.PNovelSched)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	ss.PNovelSteps = steps
+	ss.Novel = true
+	if ss.LearnPrjns != "" {
+		err = ss.FreezePrjns(true)
+		if err != nil {
+			log.Println(err)
+		}
+	} else {
+		ss.SetParamsSet("NovelLearn", "Network", true)
+	}
+	ss.InterfLog.SetNumRows(0)
 	ss.NewRun()
-	ss.OpenTrainedWts()
-	ss.SetParamsSet("NovelLearn", "Network", true)
-	ss.TrainEnv.Epoch.Cur = 40
-	ss.LrateSched(40)
-	ss.PNovel = 0.5
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////
@@ -798,6 +1111,7 @@ func (ss *Sim) TestTrial(returnOnChg bool) {
 			ss.UpdateView(false, -1)
 		}
 		ss.LogTstEpc(ss.TstEpcLog)
+		ss.TstCycLog.SetNumRows(0)
 		if returnOnChg {
 			return
 		}
@@ -806,9 +1120,12 @@ func (ss *Sim) TestTrial(returnOnChg bool) {
 	// note: type must be in place before apply inputs
 	ss.Net.LayerByName("Output").SetType(emer.Compare)
 	ss.ApplyInputs(&ss.TestEnv)
-	ss.AlphaCyc(false)   // !train
+	ss.CycStats = true
+	ss.AlphaCyc(false) // !train
+	ss.CycStats = false
 	ss.TrialStats(false) // !accumulate
 	ss.LogTstTrl(ss.TstTrlLog)
+	ss.Publish(false)
 }
 
 // TestItem tests given item which is at given index in test item list
@@ -817,15 +1134,20 @@ func (ss *Sim) TestItem(idx int) {
 	ss.TestEnv.Trial.Cur = idx
 	ss.TestEnv.DoObject(idx)
 	ss.ApplyInputs(&ss.TestEnv)
-	ss.AlphaCyc(false)   // !train
+	ss.TstCycLog.SetNumRows(0)
+	ss.CycStats = true
+	ss.AlphaCyc(false) // !train
+	ss.CycStats = false
 	ss.TrialStats(false) // !accumulate
 	ss.TestEnv.Trial.Cur = cur
+	ss.Publish(false)
 }
 
 // TestAll runs through the full set of testing items
 func (ss *Sim) TestAll() {
 	ss.TestEnv.Init(ss.TrainEnv.Run.Cur)
 	ss.ActRFs.Reset()
+	ss.TstCycLog.SetNumRows(0)
 	for {
 		ss.TestTrial(true) // return on chg, don't present
 		_, _, chg := ss.TestEnv.Counter(env.Epoch)
@@ -836,6 +1158,7 @@ func (ss *Sim) TestAll() {
 	ss.ActRFs.Avg()
 	ss.ActRFs.Norm()
 	ss.ViewActRFs()
+	ss.Publish(true)
 }
 
 // RunTestAll runs through the full set of testing items, has stop running = false at end -- for gui
@@ -984,6 +1307,25 @@ func (ss *Sim) RunName() string {
 	}
 }
 
+// ArchName returns the Arch label if set, otherwise a label for the
+// non-standard architecture options in use, or Std if none
+func (ss *Sim) ArchName() string {
+	if ss.Arch != "" {
+		return ss.Arch
+	}
+	var nms []string
+	if ss.V1Scales > 1 {
+		nms = append(nms, fmt.Sprintf("Scales%d%s", ss.V1Scales, strings.Join(strings.Fields(ss.V1ScalesTo), "")))
+	}
+	if ss.V1Angles != 4 {
+		nms = append(nms, fmt.Sprintf("Angles%d", ss.V1Angles))
+	}
+	if len(nms) == 0 {
+		return "Std"
+	}
+	return strings.Join(nms, "_")
+}
+
 // RunEpochName returns a string with the run and epoch numbers with leading zeros, suitable
 // for using in weights file names.  Uses 3, 5 digits for each.
 func (ss *Sim) RunEpochName(run, epc int) string {
@@ -992,12 +1334,17 @@ func (ss *Sim) RunEpochName(run, epc int) string {
 
 // WeightsFileName returns default current weights file name
 func (ss *Sim) WeightsFileName() string {
-	return ss.Net.Nm + "_" + ss.RunName() + "_" + ss.RunEpochName(ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur) + ".wts.gz"
+	return ss.OutFileName(ss.Net.Nm + "_" + ss.RunName() + "_" + ss.RunEpochName(ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur) + ".wts.gz")
 }
 
 // LogFileName returns default log file name
 func (ss *Sim) LogFileName(lognm string) string {
-	return ss.Net.Nm + "_" + ss.RunName() + "_" + lognm + ".tsv"
+	return ss.OutFileName(ss.Net.Nm + "_" + ss.RunName() + "_" + lognm + ".tsv")
+}
+
+// ParamsFileName returns default file name for saving the effective params
+func (ss *Sim) ParamsFileName() string {
+	return ss.OutFileName(ss.Net.Nm + "_" + ss.RunName() + "_params.json")
 }
 
 //////////////////////////////////////////////
@@ -1046,6 +1393,11 @@ func (ss *Sim) LogTrnEpc(dt *etable.Table) {
 	dt.SetCellFloat("PctCor", row, ss.EpcPctCor)
 	dt.SetCellFloat("CosDiff", row, ss.EpcCosDiff)
 	dt.SetCellFloat("PerTrlMSec", row, ss.EpcPerTrlMSec)
+	xr := &ss.TrainEnv.XFormRand
+	dt.SetCellFloat("XFormFrac", row, float64(ss.Curric.Frac))
+	dt.SetCellFloat("TransMax", row, float64(xr.TransX.Max))
+	dt.SetCellFloat("ScaleMin", row, float64(xr.Scale.Min))
+	dt.SetCellFloat("RotMax", row, float64(xr.Rot.Max))
 
 	for _, lnm := range ss.LayStatNms {
 		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
@@ -1077,6 +1429,10 @@ func (ss *Sim) ConfigTrnEpcLog(dt *etable.Table) {
 		{"PctCor", etensor.FLOAT64, nil, nil},
 		{"CosDiff", etensor.FLOAT64, nil, nil},
 		{"PerTrlMSec", etensor.FLOAT64, nil, nil},
+		{"XFormFrac", etensor.FLOAT64, nil, nil},
+		{"TransMax", etensor.FLOAT64, nil, nil},
+		{"ScaleMin", etensor.FLOAT64, nil, nil},
+		{"RotMax", etensor.FLOAT64, nil, nil},
 	}
 	for _, lnm := range ss.LayStatNms {
 		sch = append(sch, etable.Column{lnm + " ActAvg", etensor.FLOAT64, nil, nil})
@@ -1097,6 +1453,10 @@ func (ss *Sim) ConfigTrnEpcPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot
 	plt.SetColParams("PctCor", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
 	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
 	plt.SetColParams("PerTrlMSec", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("XFormFrac", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TransMax", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("ScaleMin", eplot.Off, eplot.FloatMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("RotMax", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
 
 	for _, lnm := range ss.LayStatNms {
 		plt.SetColParams(lnm+" ActAvg", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 0.5)
@@ -1130,6 +1490,7 @@ func (ss *Sim) LogTstTrl(dt *etable.Table) {
 	dt.SetCellFloat("SSE", row, ss.TrlSSE)
 	dt.SetCellFloat("AvgSSE", row, ss.TrlAvgSSE)
 	dt.SetCellFloat("CosDiff", row, ss.TrlCosDiff)
+	dt.SetCellFloat("RT", row, ss.TrlRT)
 
 	for _, lnm := range ss.LayStatNms {
 		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
@@ -1137,6 +1498,12 @@ func (ss *Sim) LogTstTrl(dt *etable.Table) {
 	}
 	// note: essential to use Go version of update when called from another goroutine
 	ss.TstTrlPlot.GoUpdate()
+	if ss.TstTrlFile != nil {
+		dt.WriteCSVRow(ss.TstTrlFile, row, etable.Tab)
+	}
+	if ss.ActLog {
+		ss.LogTstAct(ss.TstActLog)
+	}
 }
 
 func (ss *Sim) ConfigTstTrlLog(dt *etable.Table) {
@@ -1159,6 +1526,7 @@ func (ss *Sim) ConfigTstTrlLog(dt *etable.Table) {
 		{"SSE", etensor.FLOAT64, nil, nil},
 		{"AvgSSE", etensor.FLOAT64, nil, nil},
 		{"CosDiff", etensor.FLOAT64, nil, nil},
+		{"RT", etensor.FLOAT64, nil, nil},
 	}
 	for _, lnm := range ss.LayStatNms {
 		sch = append(sch, etable.Column{lnm + " ActM.Avg", etensor.FLOAT64, nil, nil})
@@ -1180,6 +1548,7 @@ func (ss *Sim) ConfigTstTrlPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot
 	plt.SetColParams("SSE", eplot.On, eplot.FixMin, 0, eplot.FloatMax, 0) // default plot
 	plt.SetColParams("AvgSSE", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
 	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("RT", eplot.Off, eplot.FloatMin, 0, eplot.FloatMax, 0)
 
 	for _, lnm := range ss.LayStatNms {
 		plt.SetColParams(lnm+" ActM.Avg", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 0.5)
@@ -1187,13 +1556,122 @@ func (ss *Sim) ConfigTstTrlPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot
 	return plt
 }
 
+//////////////////////////////////////////////
+//  TstActLog
+
+// TstActLays are the layers whose ActM activations are recorded in the TstActLog
+var TstActLays = []string{"V4", "IT", "Output"}
+
+// LogTstAct adds the V1 input and layer activations from current trial to the TstActLog table.
+// log always contains number of testing items
+func (ss *Sim) LogTstAct(dt *etable.Table) {
+	row := ss.TestEnv.Trial.Cur
+	if dt.Rows <= row {
+		dt.SetNumRows(row + 1)
+	}
+
+	dt.SetCellFloat("Trial", row, float64(row))
+	dt.SetCellFloat("Obj", row, float64(ss.TestEnv.CurLED))
+	dt.SetCellTensor("V1", row, &ss.TestEnv.Vis.V1AllTsr)
+	for _, lnm := range TstActLays {
+		ly := ss.Net.LayerByName(lnm)
+		vt := ss.ValsTsr(lnm + "Act")
+		ly.UnitValsTensor(vt, "ActM")
+		dt.SetCellTensor(lnm, row, vt)
+	}
+}
+
+func (ss *Sim) ConfigTstActLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstActLog")
+	dt.SetMetaData("desc", "Record of V1 input and layer activations per testing input pattern")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	nt := ss.TestEnv.Trial.Max
+	v1 := ss.Net.LayerByName("V1").(leabra.LeabraLayer).AsLeabra()
+	sch := etable.Schema{
+		{"Trial", etensor.INT64, nil, nil},
+		{"Obj", etensor.INT64, nil, nil},
+		{"V1", etensor.FLOAT32, v1.Shp.Shp, nil},
+	}
+	for _, lnm := range TstActLays {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		sch = append(sch, etable.Column{lnm, etensor.FLOAT32, ly.Shp.Shp, nil})
+	}
+	dt.SetFromSchema(sch, nt)
+}
+
+//////////////////////////////////////////////
+//  TstCycLog
+
+// LogTstCyc adds data from current cycle to the TstCycLog table.
+// log is reset at the start of each testing epoch, so it contains
+// the cycles of all the trials tested so far in the epoch.
+func (ss *Sim) LogTstCyc(dt *etable.Table, cyc int, corAct, maxOth float32) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	dt.SetCellFloat("Trial", row, float64(ss.TestEnv.Trial.Cur))
+	dt.SetCellFloat("Cycle", row, float64(cyc))
+	dt.SetCellFloat("Obj", row, float64(ss.TestEnv.CurLED))
+	dt.SetCellFloat("CorAct", row, float64(corAct))
+	dt.SetCellFloat("MaxOthAct", row, float64(maxOth))
+
+	for _, lnm := range []string{"IT", "Output"} {
+		ly := ss.Net.LayerByName(lnm)
+		vt := ss.ValsTsr(lnm + "Cyc") // separate from ActM vals used in ActRFs
+		ly.UnitValsTensor(vt, "Act")
+		dt.SetCellTensor(lnm, row, vt)
+	}
+	if ss.TstCycFile != nil {
+		dt.WriteCSVRow(ss.TstCycFile, row, etable.Tab)
+	}
+	// note: essential to use Go version of update when called from another goroutine
+	ss.TstCycPlot.GoUpdate()
+}
+
+func (ss *Sim) ConfigTstCycLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstCycLog")
+	dt.SetMetaData("desc", "Record of activations over cycles of each trial of current testing epoch")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Trial", etensor.INT64, nil, nil},
+		{"Cycle", etensor.INT64, nil, nil},
+		{"Obj", etensor.INT64, nil, nil},
+		{"CorAct", etensor.FLOAT64, nil, nil},
+		{"MaxOthAct", etensor.FLOAT64, nil, nil},
+	}
+	for _, lnm := range []string{"IT", "Output"} {
+		ly := ss.Net.LayerByName(lnm).(leabra.LeabraLayer).AsLeabra()
+		sch = append(sch, etable.Column{lnm, etensor.FLOAT64, ly.Shp.Shp, nil})
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
+func (ss *Sim) ConfigTstCycPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D {
+	plt.Params.Title = "Object Recognition Test Cycle Plot"
+	plt.Params.XAxisCol = "Cycle"
+	plt.SetTable(dt)
+	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Trial", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Cycle", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Obj", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("CorAct", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1) // default plot
+	plt.SetColParams("MaxOthAct", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("IT", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("Output", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	return plt
+}
+
 //////////////////////////////////////////////
 //  TstEpcLog
 
 func (ss *Sim) LogTstEpc(dt *etable.Table) {
 	trl := ss.TstTrlLog
 	tix := etable.NewIdxView(trl)
-	// epc := ss.TrainEnv.Epoch.Prv // ?
+	epc := ss.TrainEnv.Epoch.Prv // this is triggered by increment so use previous value
 
 	spl := split.GroupBy(tix, []string{"Obj"})
 	_, err := split.AggTry(spl, "Err", agg.AggMean)
@@ -1204,10 +1682,18 @@ func (ss *Sim) LogTstEpc(dt *etable.Table) {
 	no := objs.Rows
 	dt.SetNumRows(no)
 	for i := 0; i < no; i++ {
-		dt.SetCellFloat("Obj", i, float64(i))
+		dt.SetCellFloat("Run", i, float64(ss.TrainEnv.Run.Cur))
+		dt.SetCellFloat("Epoch", i, float64(epc))
+		dt.SetCellFloat("Obj", i, objs.Cols[0].FloatVal1D(i))
 		dt.SetCellFloat("PctErr", i, objs.Cols[1].FloatVal1D(i))
 	}
 	ss.TstEpcPlot.GoUpdate()
+	if ss.TstEpcFile != nil {
+		for i := 0; i < no; i++ {
+			dt.WriteCSVRow(ss.TstEpcFile, i, etable.Tab)
+		}
+	}
+	ss.LogTstHist(ss.TstHistLog)
 }
 
 func (ss *Sim) ConfigTstEpcLog(dt *etable.Table) {
@@ -1217,6 +1703,8 @@ func (ss *Sim) ConfigTstEpcLog(dt *etable.Table) {
 	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
 
 	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
 		{"Obj", etensor.INT64, nil, nil},
 		{"PctErr", etensor.FLOAT64, nil, nil},
 	}
@@ -1229,11 +1717,53 @@ func (ss *Sim) ConfigTstEpcPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot
 	plt.Params.Type = eplot.Bar
 	plt.SetTable(dt)
 	// order of params: on, fixMin, min, fixMax, max
+	plt.SetColParams("Run", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
+	plt.SetColParams("Epoch", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
 	plt.SetColParams("Obj", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
 	plt.SetColParams("PctErr", eplot.On, eplot.FixMin, 0, eplot.FixMax, 1)
 	return plt
 }
 
+//////////////////////////////////////////////
+//  TstHistLog
+
+// TestedEpoch returns true if the last TestAll in the TstHistLog was after
+// given epoch of the current run
+func (ss *Sim) TestedEpoch(epc int) bool {
+	th := ss.TstHistLog
+	if th.Rows == 0 {
+		return false
+	}
+	return int(th.CellFloat("Run", th.Rows-1)) == ss.TrainEnv.Run.Cur && int(th.CellFloat("Epoch", th.Rows-1)) == epc
+}
+
+// LogTstHist adds the overall accuracy of the last TestAll to the TstHistLog
+func (ss *Sim) LogTstHist(dt *etable.Table) {
+	row := dt.Rows
+	dt.SetNumRows(row + 1)
+
+	pcterr := ss.TstPctErr()
+	dt.SetCellFloat("Run", row, float64(ss.TrainEnv.Run.Cur))
+	dt.SetCellFloat("Epoch", row, float64(ss.TrainEnv.Epoch.Prv))
+	dt.SetCellFloat("PctErr", row, pcterr)
+	dt.SetCellFloat("PctCor", row, 1-pcterr)
+}
+
+func (ss *Sim) ConfigTstHistLog(dt *etable.Table) {
+	dt.SetMetaData("name", "TstHistLog")
+	dt.SetMetaData("desc", "History of overall testing accuracy over the run")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Run", etensor.INT64, nil, nil},
+		{"Epoch", etensor.INT64, nil, nil},
+		{"PctErr", etensor.FLOAT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+	}
+	dt.SetFromSchema(sch, 0)
+}
+
 //////////////////////////////////////////////
 //  RunLog
 
@@ -1252,22 +1782,31 @@ func (ss *Sim) LogRun(dt *etable.Table) {
 	}
 	epcix.Idxs = epcix.Idxs[epcix.Len()-nlast:]
 
-	// params := ss.Params.Name
-	params := "params"
+	// testing accuracy from the last TestAll in this run, -1 if none
+	tstcor := -1.0
+	if th := ss.TstHistLog; th.Rows > 0 && int(th.CellFloat("Run", th.Rows-1)) == run {
+		tstcor = th.CellFloat("PctCor", th.Rows-1)
+	}
 
 	dt.SetCellFloat("Run", row, float64(run))
-	dt.SetCellString("Params", row, params)
+	dt.SetCellString("Params", row, ss.ParamsName())
+	dt.SetCellString("Tag", row, ss.Tag)
+	dt.SetCellString("Arch", row, ss.ArchName())
 	dt.SetCellFloat("FirstZero", row, float64(ss.FirstZero))
 	dt.SetCellFloat("SSE", row, agg.Mean(epcix, "SSE")[0])
 	dt.SetCellFloat("AvgSSE", row, agg.Mean(epcix, "AvgSSE")[0])
 	dt.SetCellFloat("PctErr", row, agg.Mean(epcix, "PctErr")[0])
 	dt.SetCellFloat("PctCor", row, agg.Mean(epcix, "PctCor")[0])
 	dt.SetCellFloat("CosDiff", row, agg.Mean(epcix, "CosDiff")[0])
+	dt.SetCellFloat("TstPctCor", row, tstcor)
+	dt.SetCellFloat("Epochs", row, float64(ss.TrainEnv.Epoch.Cur))
+	dt.SetCellString("StopRule", row, ss.StopRule)
 
 	runix := etable.NewIdxView(dt)
-	spl := split.GroupBy(runix, []string{"Params"})
+	spl := split.GroupBy(runix, []string{"Params", "Tag", "Arch"})
 	split.Desc(spl, "FirstZero")
 	split.Desc(spl, "PctCor")
+	split.Desc(spl, "TstPctCor")
 	ss.RunStats = spl.AggsToTable(etable.AddAggName)
 
 	// note: essential to use Go version of update when called from another goroutine
@@ -1289,12 +1828,17 @@ func (ss *Sim) ConfigRunLog(dt *etable.Table) {
 	sch := etable.Schema{
 		{"Run", etensor.INT64, nil, nil},
 		{"Params", etensor.STRING, nil, nil},
+		{"Tag", etensor.STRING, nil, nil},
+		{"Arch", etensor.STRING, nil, nil},
 		{"FirstZero", etensor.FLOAT64, nil, nil},
 		{"SSE", etensor.FLOAT64, nil, nil},
 		{"AvgSSE", etensor.FLOAT64, nil, nil},
 		{"PctErr", etensor.FLOAT64, nil, nil},
 		{"PctCor", etensor.FLOAT64, nil, nil},
 		{"CosDiff", etensor.FLOAT64, nil, nil},
+		{"TstPctCor", etensor.FLOAT64, nil, nil},
+		{"Epochs", etensor.INT64, nil, nil},
+		{"StopRule", etensor.STRING, nil, nil},
 	}
 	dt.SetFromSchema(sch, 0)
 }
@@ -1311,6 +1855,8 @@ func (ss *Sim) ConfigRunPlot(plt *eplot.Plot2D, dt *etable.Table) *eplot.Plot2D
 	plt.SetColParams("PctErr", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
 	plt.SetColParams("PctCor", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
 	plt.SetColParams("CosDiff", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("TstPctCor", eplot.Off, eplot.FixMin, 0, eplot.FixMax, 1)
+	plt.SetColParams("Epochs", eplot.Off, eplot.FixMin, 0, eplot.FloatMax, 0)
 	return plt
 }
 
@@ -1372,12 +1918,22 @@ func (ss *Sim) ConfigGui() *gi.Window {
 	plt = tv.AddNewTab(eplot.KiT_Plot2D, "TstTrlPlot").(*eplot.Plot2D)
 	ss.TstTrlPlot = ss.ConfigTstTrlPlot(plt, ss.TstTrlLog)
 
+	plt = tv.AddNewTab(eplot.KiT_Plot2D, "TstCycPlot").(*eplot.Plot2D)
+	ss.TstCycPlot = ss.ConfigTstCycPlot(plt, ss.TstCycLog)
+
 	plt = tv.AddNewTab(eplot.KiT_Plot2D, "TstEpcPlot").(*eplot.Plot2D)
 	ss.TstEpcPlot = ss.ConfigTstEpcPlot(plt, ss.TstEpcLog)
 
 	plt = tv.AddNewTab(eplot.KiT_Plot2D, "RunPlot").(*eplot.Plot2D)
 	ss.RunPlot = ss.ConfigRunPlot(plt, ss.RunLog)
 
+	plt = tv.AddNewTab(eplot.KiT_Plot2D, "InterfPlot").(*eplot.Plot2D)
+	ss.InterfPlot = ss.ConfigInterfPlot(plt, ss.InterfLog)
+
+	gv := tv.AddNewTab(etview.KiT_TableView, "GenLog").(*etview.TableView)
+	gv.SetTable(ss.GenLog, nil)
+	ss.GenView = gv
+
 	ss.ActRFGrids = make(map[string]*etview.TensorGrid)
 	for _, nm := range ss.ActRFNms {
 		tg := tv.AddNewTab(etview.KiT_TensorGrid, nm).(*etview.TensorGrid)
@@ -1444,14 +2000,13 @@ func (ss *Sim) ConfigGui() *gi.Window {
 
 	tbar.AddSeparator("spcl")
 
-	tbar.AddAction(gi.ActOpts{Label: "Open Trained Wts", Icon: "update", Tooltip: "open weights trained on first phase of training (excluding 'novel' objects)", UpdateFunc: func(act *gi.Action) {
+	tbar.AddAction(gi.ActOpts{Label: "Open Trained Wts", Icon: "update", Tooltip: "choose and open weights trained on first phase of training (excluding 'novel' objects) -- embedded objrec_train1 or objrec_train2, or a weights file -- sets TrainedWts used by Train Novel", UpdateFunc: func(act *gi.Action) {
 		act.SetActiveStateUpdt(!ss.IsRunning)
 	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
-		ss.OpenTrainedWts()
-		vp.SetNeedsFullRender()
+		ss.ChooseTrainedWts(vp)
 	})
 
-	tbar.AddAction(gi.ActOpts{Label: "Train Novel", Icon: "update", Tooltip: "prepares network for training novel items: loads saved weight, changes PNovel -- just do Step Run after this..", UpdateFunc: func(act *gi.Action) {
+	tbar.AddAction(gi.ActOpts{Label: "Train Novel", Icon: "update", Tooltip: "prepares network for training novel items: loads saved weight, changes PNovel per PNovelSched, and records accuracy on trained vs. novel items in InterfPlot -- just do Step Run after this..", UpdateFunc: func(act *gi.Action) {
 		act.SetActiveStateUpdt(!ss.IsRunning)
 	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
 		ss.TrainNovel()
@@ -1502,6 +2057,22 @@ func (ss *Sim) ConfigGui() *gi.Window {
 		}
 	})
 
+	tbar.AddAction(gi.ActOpts{Label: "Gen All", Icon: "fast-fwd", Tooltip: "Top-down generation test: clamps each Output unit in turn with an empty (or noisy, per GenNoise) V1, and records the V4 and IT patterns in GenLog -- run Test All first to project these into image space through the ActRFs.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		if !ss.IsRunning {
+			ss.IsRunning = true
+			tbar.UpdateActions()
+			go ss.GenAll()
+		}
+	})
+
+	tbar.AddAction(gi.ActOpts{Label: "Save Gallery", Icon: "file-save", Tooltip: "Saves the GenLog and an image of each object's projected V4 and IT patterns into a directory.", UpdateFunc: func(act *gi.Action) {
+		act.SetActiveStateUpdt(!ss.IsRunning)
+	}}, win.This(), func(recv, send ki.Ki, sig int64, data interface{}) {
+		giv.CallMethod(ss, "SaveGallery", vp)
+	})
+
 	tbar.AddSeparator("log")
 
 	tbar.AddAction(gi.ActOpts{Label: "Reset RunLog", Icon: "update", Tooltip: "Reset the accumulated log of all Runs, which are tagged with the ParamSet used"}, win.This(),
@@ -1606,6 +2177,24 @@ var SimProps = ki.Props{
 				}},
 			},
 		}},
+		{"OpenWtsFile", ki.Props{
+			"desc": "open trained weights from file",
+			"icon": "file-open",
+			"Args": ki.PropSlice{
+				{"File Name", ki.Props{
+					"ext": ".wts,.wts.gz",
+				}},
+			},
+		}},
+		{"SaveGallery", ki.Props{
+			"desc": "save top-down generation gallery to directory",
+			"icon": "file-save",
+			"Args": ki.PropSlice{
+				{"Dir", ki.Props{
+					"default": "gallery",
+				}},
+			},
+		}},
 	},
 }
 
@@ -1614,19 +2203,149 @@ func (ss *Sim) CmdArgs() {
 	var nogui bool
 	var saveEpcLog bool
 	var saveRunLog bool
+	var saveTstLog bool
+	var saveTstEpcLog bool
+	var testInterval int
 	var note string
+	var rfDir string
+	var split string
+	var tstTrls int
+	var paramsFile string
+	var saveParams bool
+	var outDir string
+	var saveNpz bool
+	var initWts string
+	var invert bool
+	var asJSON bool
+	var addr string
+	var dashAddr string
+	var curricStart float64
+	var novel bool
+	var cont bool
+	var curricStep float64
+	var perms int
+	var v1Bank int
+	var prefetch int
+	var prefetchQ int
+	var rtThr float64
+	var rtMargin float64
 	flag.StringVar(&ss.ParamSet, "params", "", "ParamSet name to use -- must be valid name as listed in compiled-in params or loaded params")
 	flag.StringVar(&ss.Tag, "tag", "", "extra tag to add to file names saved from this run")
+	flag.StringVar(&ss.Arch, "arch", "", "label for the architecture / topography variant of this run, recorded in the run log to separate conditions for the compare command")
+	flag.IntVar(&ss.V1Scales, "v1scales", 1, "number of V1 filtering scales -- each scale beyond the first has twice the gabor size and spacing of the previous, and its own V1ScaleN input layer")
+	flag.IntVar(&ss.V1Angles, "v1angles", 4, "number of gabor filter orientations in V1, e.g., 4, 6 or 8 -- the V1 layer shapes follow")
+	flag.IntVar(&v1Bank, "v1bank", 0, "if > 0, training draws each object from a bank of this many V1 filtered random transforms per object, filled as it goes, which is much faster but less varied -- testing is not affected")
+	flag.IntVar(&prefetch, "prefetch", 0, "if > 0, training stimuli are generated ahead in this many background workers, overlapping with network settling -- the stimulus sequence is deterministic for a given random seed, but differs from the one without prefetch -- not used with -v1bank")
+	flag.IntVar(&prefetchQ, "prefetchq", 16, "maximum number of training stimuli queued ahead with -prefetch")
+	flag.StringVar(&ss.V1ScalesTo, "v1scalesto", "IT", "layers that the coarser -v1scales V1 layers project to, space-separated, e.g., 'IT' or 'V4 IT'")
 	flag.StringVar(&note, "note", "", "user note -- describe the run params etc")
+	flag.StringVar(&paramsFile, "paramsfile", "", "name of .json or .toml file with params.Sets to merge over the compiled-in params")
+	flag.BoolVar(&saveParams, "saveparams", true, "if true, save the effective merged params used for the run to file")
 	flag.IntVar(&ss.MaxRuns, "runs", 1, "number of runs to do (note that MaxEpcs is in paramset)")
+	flag.IntVar(&ss.NZeroStop, "nzerostop", -1, "if > 0, stop a run after this many epochs in a row with zero training errors")
+	flag.Float64Var(&ss.MaxMins, "maxmins", 0, "if > 0, stop a run after this many minutes of wall-clock time")
+	flag.IntVar(&ss.TstPlateau, "tstplateau", 0, "if > 0, stop a run when testing accuracy has not improved by more than -tstplateautol over this many epochs -- tests every -testinterval epochs")
+	flag.Float64Var(&ss.TstPlateauTol, "tstplateautol", 0, "minimum improvement in testing accuracy over -tstplateau epochs to keep training")
+	flag.BoolVar(&ss.Curric.On, "curric", false, "if true, train with a curriculum that widens the random transform ranges from -curricstart of their full extent")
+	flag.Float64Var(&curricStart, "curricstart", 0.2, "starting fraction of the full transform ranges for -curric")
+	flag.Float64Var(&curricStep, "curricstep", 0.2, "fraction of the full transform ranges added at each -curric widening")
+	flag.IntVar(&ss.Curric.Epochs, "curricepcs", 0, "if > 0, widen -curric transform ranges every this many epochs")
+	flag.Float64Var(&ss.Curric.ErrThr, "curricerr", 0, "if > 0, widen -curric transform ranges when the training epoch PctErr is at or below this value")
+	flag.Float64Var(&ss.TargCosDiff, "targcosdiff", 0, "if > 0, stop a run when the training epoch CosDiff reaches this value")
 	flag.BoolVar(&ss.LogSetParams, "setparams", false, "if true, print a record of each parameter that is set")
 	flag.BoolVar(&ss.SaveWts, "wts", false, "if true, save final weights after each run")
+	flag.StringVar(&initWts, "initwts", "", fmt.Sprintf("trained weights to start each run from, and to use for test and actrfs commands if no weights files given: embedded name (%s) or path to weights file", strings.Join(EmbedWtsNames(), ", ")))
 	flag.BoolVar(&saveEpcLog, "epclog", true, "if true, save train epoch log to file")
 	flag.BoolVar(&saveRunLog, "runlog", true, "if true, save run epoch log to file")
+	flag.BoolVar(&saveTstLog, "tstlog", false, "if true, save test trial log to file, from testing every -testinterval epochs")
+	flag.BoolVar(&saveTstEpcLog, "tstepclog", false, "if true, save test per-object summary log to file, from testing every -testinterval epochs")
+	flag.IntVar(&testInterval, "testinterval", 5, "how often to test during training, in epochs, if -tstlog, -tstepclog or -cyclog is set")
 	flag.BoolVar(&nogui, "nogui", true, "if not passing any other args and want to run nogui, use nogui")
+	flag.StringVar(&outDir, "outdir", "runs", "directory in which a new run directory is made to hold all the output files and manifest of this run")
+	flag.StringVar(&rfDir, "rfdir", "actrfs", "directory to save activation-based receptive fields into, for the actrfs command")
+	flag.StringVar(&split, "split", "all", "which objects to test for the test, sweep and search commands: all, trained, or novel")
+	flag.BoolVar(&saveNpz, "npz", false, "if true, the test command also saves per-trial V1 input and V4, IT, Output activations, and all projection weights, as numpy .npz files")
+	flag.BoolVar(&invert, "invert", false, "if true, the classify command inverts images, for dark drawings on a light background")
+	flag.BoolVar(&asJSON, "json", false, "if true, the classify command prints results as JSON")
+	flag.StringVar(&addr, "addr", "localhost:8080", "address for the serve command to listen on -- keep to localhost, as there is no authentication")
+	flag.StringVar(&dashAddr, "dashboard", "", "if set, address on which to serve a live dashboard of training and test curves, and the control API, while training -- e.g., localhost:8080")
+	flag.BoolVar(&novel, "novel", false, "if true, train novel items starting from the -initwts trained weights (default objrec_train1), recording accuracy on trained vs. novel items every epoch")
+	flag.StringVar(&ss.LearnPrjns, "learnprjns", "", "if set, only these projections learn when training or fine-tuning from -initwts, and all others are frozen -- space-separated list of #PrjnName, .Class, or receiving layer name entries, e.g., 'IT Output'")
+	flag.StringVar(&ss.PNovelSched, "pnovelsched", "", "schedule of PNovel over epochs of -novel training, as space-separated epoch:pnovel entries with pnovel in 0-1, e.g., '0:0.5 10:0.25' -- default 0.5 throughout")
+	flag.IntVar(&ss.InterfTrls, "interftrls", 100, "number of held-out test trials on each of the trained and novel items after every epoch of -novel training")
+	flag.StringVar(&ss.Cont.Stages, "stages", "10 5 5", "number of classes introduced at each stage of the continual command, space-separated")
+	flag.IntVar(&ss.Cont.MaxEpcs, "stageepcs", 50, "maximum number of epochs to train each stage of the continual command")
+	flag.Float64Var(&ss.Cont.CritErr, "stagecrit", 0, "training epoch PctErr at or below which a stage of the continual command is at criterion")
+	flag.IntVar(&ss.Cont.CritN, "stagecritn", 2, "number of epochs in a row at -stagecrit to finish a stage of the continual command")
+	flag.IntVar(&perms, "perms", 10000, "number of random permutations for the permutation tests of the compare command -- 0 = Welch t-tests only")
+	flag.BoolVar(&ss.CycLog, "cyclog", false, "if true, record cycle-by-cycle Output and IT activations during testing, with the reaction time stats, and save them to file -- a _tstcyc log from testing every -testinterval epochs, or per weights file for the test command -- slows testing")
+	flag.Float64Var(&rtThr, "rtthr", 0.5, "reaction time threshold: the RT of a test trial is the first cycle at which the correct Output unit activation reaches this value, or wins by -rtmargin -- 0 = not used")
+	flag.Float64Var(&rtMargin, "rtmargin", 0, "reaction time margin: the RT of a test trial is the first cycle at which the correct Output unit activation exceeds that of all other Output units by this amount, or reaches -rtthr -- 0 = not used")
+	flag.IntVar(&tstTrls, "tsttrls", 0, "number of testing trials for the test command -- 0 = default")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [command [args]]\n", os.Args[0])
+		fmt.Fprintf(flag.CommandLine.Output(), "with no command, trains the network.  Commands:\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  actrfs <weights files>: runs TestAll on each weights file and saves activation-based receptive fields into -rfdir\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  test <weights files>: runs TestAll on -split of objects for each weights file and saves test logs and summary\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  classify <image files>: classifies each image using the -initwts trained weights (default objrec_train1)\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  continual: trains classes in -stages, each to criterion, testing all classes seen so far after each stage, for each of -runs\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  sweep <spec.json>: trains every combination of the parameter values in the sweep spec for a number of seeds, testing on -split, and saves all the results in one table\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  search <spec.json>: random or successive halving search over the parameter ranges in the search spec, saving every trial and the best params\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  compare <run logs>: compares PctCor, FirstZero and TstPctCor between the conditions (Params, Tag, Arch) in the run logs, with means, SEs, CIs, Welch t-tests and permutation tests\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "  serve: serves a local HTTP/JSON API on -addr to control the sim and get its state and logs, and a dashboard at /\n")
+		flag.PrintDefaults()
+	}
 	flag.Parse()
+	if ss.V1Angles < 2 {
+		log.Printf("v1angles: %d must be at least 2 -- using 4\n", ss.V1Angles)
+		ss.V1Angles = 4
+	}
+	if ss.V1Scales != 1 || ss.V1Angles != 4 { // network architecture changed: reconfigure
+		ss.Net = &leabra.Network{}
+		ss.Config()
+	}
+	if _, err := ParsePNovelSched(ss.PNovelSched); err != nil {
+		fmt.Printf("pnovelsched: %v\n", err)
+		return
+	}
+	if prefetch > 0 && v1Bank > 0 {
+		log.Printf("prefetch: not used with -v1bank, which is faster already\n")
+		prefetch = 0
+	}
+	ss.TrainEnv.Bank.N = v1Bank
+	ss.NovelTrainEnv.Bank.N = v1Bank
+	for _, ev := range []*LEDEnv{&ss.TrainEnv, &ss.NovelTrainEnv} {
+		ev.Prefetch.Workers = prefetch
+		ev.Prefetch.QueueSize = prefetchQ
+	}
+	ss.RTThr = float32(rtThr)
+	ss.RTMargin = float32(rtMargin)
+	ss.Curric.StartFrac = float32(curricStart)
+	ss.Curric.Step = float32(curricStep)
+	if paramsFile != "" {
+		err := ss.OpenParamsFile(paramsFile)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	if initWts != "" {
+		ss.TrainedWts = initWts
+		ss.RunFromTrained = true
+	}
+	ss.TrainEnv.Run.Max = ss.MaxRuns // set after ConfigEnv, so update
+	ss.NovelTrainEnv.Run.Max = ss.MaxRuns
 	ss.Init()
 
+	switch flag.Arg(0) {
+	case "", "continual", "test", "actrfs", "sweep", "search": // training / testing: outputs go in a run dir with a manifest
+		err := ss.MakeRunDir(outDir)
+		if err != nil {
+			log.Println(err)
+		}
+		ss.InitManifest(note)
+		defer ss.EndManifest() // also finalized by HandleSignals on quitting
+	}
+
 	if note != "" {
 		fmt.Printf("note: %s\n", note)
 	}
@@ -1634,6 +2353,55 @@ func (ss *Sim) CmdArgs() {
 		fmt.Printf("Using ParamSet: %s\n", ss.ParamSet)
 	}
 
+	switch flag.Arg(0) {
+	case "":
+	case "actrfs":
+		ss.CmdActRFs(rfDir, flag.Args()[1:])
+		return
+	case "test":
+		if tstTrls > 0 {
+			ss.TestEnv.Trial.Max = tstTrls
+			ss.ConfigTstTrlLog(ss.TstTrlLog)
+			ss.ConfigTstActLog(ss.TstActLog)
+		}
+		ss.ActLog = saveNpz
+		ss.CmdTest(split, flag.Args()[1:])
+		return
+	case "classify":
+		ss.CmdClassify(flag.Args()[1:], invert, asJSON)
+		return
+	case "continual":
+		cont = true
+	case "sweep":
+		ss.HandleSignals()
+		ss.CmdSweep(flag.Arg(1), split)
+		return
+	case "search":
+		ss.HandleSignals()
+		ss.CmdSearch(flag.Arg(1), split)
+		return
+	case "compare":
+		ss.CmdCompare(flag.Args()[1:], perms)
+		return
+	case "serve":
+		err := ss.Serve(addr)
+		if err != nil {
+			log.Println(err)
+		}
+		return
+	default:
+		fmt.Printf("unknown command: %s\n", flag.Arg(0))
+		flag.Usage()
+		return
+	}
+
+	if saveParams {
+		err := ss.SaveEffectiveParams(ss.ParamsFileName())
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
 	if saveEpcLog {
 		var err error
 		fnm := ss.LogFileName("epc")
@@ -1658,9 +2426,96 @@ func (ss *Sim) CmdArgs() {
 			defer ss.RunFile.Close()
 		}
 	}
+	if saveTstLog {
+		var err error
+		fnm := ss.LogFileName("tsttrl")
+		ss.TstTrlFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.TstTrlFile = nil
+		} else {
+			fmt.Printf("Saving test trial log to: %s\n", fnm)
+			ss.TstTrlLog.WriteCSVHeaders(ss.TstTrlFile, etable.Tab)
+			defer ss.TstTrlFile.Close()
+		}
+	}
+	if saveTstEpcLog {
+		var err error
+		fnm := ss.LogFileName("tstepc")
+		ss.TstEpcFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.TstEpcFile = nil
+		} else {
+			fmt.Printf("Saving test epoch log to: %s\n", fnm)
+			ss.TstEpcLog.WriteCSVHeaders(ss.TstEpcFile, etable.Tab)
+			defer ss.TstEpcFile.Close()
+		}
+	}
+	if ss.CycLog {
+		var err error
+		fnm := ss.LogFileName("tstcyc")
+		ss.TstCycFile, err = os.Create(fnm)
+		if err != nil {
+			log.Println(err)
+			ss.TstCycFile = nil
+		} else {
+			fmt.Printf("Saving test cycle log to: %s\n", fnm)
+			ss.TstCycLog.WriteCSVHeaders(ss.TstCycFile, etable.Tab)
+			defer ss.TstCycFile.Close()
+		}
+	}
+	if saveTstLog || saveTstEpcLog || ss.CycLog || dashAddr != "" || ss.TstPlateau > 0 {
+		ss.TestInterval = testInterval
+		fmt.Printf("Testing every %d epochs\n", ss.TestInterval)
+	}
+	ss.IsRunning = true // so control API does not start another run
+	if dashAddr != "" {
+		sv := ss.NewServer()
+		go func() {
+			err := sv.ListenAndServe(dashAddr)
+			if err != nil {
+				log.Println(err)
+			}
+		}()
+	}
 	if ss.SaveWts {
 		fmt.Printf("Saving final weights per run\n")
 	}
+	if novel {
+		ss.Manifest.Novel = true
+		ss.TrainNovel()
+		ss.Manifest.Learning = ss.LearningPrjns()
+		ss.Manifest.Frozen = ss.FrozenPrjns()
+		fmt.Printf("Training novel items from: %s\n", ss.TrainedWts)
+	}
 	fmt.Printf("Running %d Runs\n", ss.MaxRuns)
-	ss.Train()
+	ss.HandleSignals()
+	if cont {
+		err := ss.TrainContinual()
+		if err != nil {
+			log.Println(err)
+		}
+		fnm := ss.LogFileName("cont")
+		err = ss.ContLog.SaveCSV(gi.FileName(fnm), etable.Tab, etable.Headers)
+		if err != nil {
+			log.Println(err)
+		} else {
+			fmt.Printf("Saved continual stage log to: %s\n", fnm)
+		}
+	} else {
+		ss.Train()
+	}
+	if ss.Interrupted {
+		ss.InterruptEnd()
+	}
+	if novel {
+		fnm := ss.LogFileName("interf")
+		err := ss.InterfLog.SaveCSV(gi.FileName(fnm), etable.Tab, etable.Headers)
+		if err != nil {
+			log.Println(err)
+		} else {
+			fmt.Printf("Saved interference log to: %s\n", fnm)
+		}
+	}
 }
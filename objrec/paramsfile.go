@@ -0,0 +1,274 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/emer/emergent/params"
+	"github.com/emer/leabra/leabra"
+	"github.com/goki/gi/gi"
+)
+
+// OpenParamsFile loads params.Sets from given .json or .toml file, and merges
+// them over the current Params: sets with the same name are merged sheet by
+// sheet and selector by selector, with the file values taking precedence,
+// and new sets are added.  Prints a warning for each selector or parameter
+// path in the file that does not apply to this simulation.
+func (ss *Sim) OpenParamsFile(fnm string) error {
+	var ps params.Sets
+	var err error
+	if strings.ToLower(filepath.Ext(fnm)) == ".toml" {
+		err = ps.OpenTOML(gi.FileName(fnm))
+	} else {
+		err = ps.OpenJSON(gi.FileName(fnm))
+	}
+	if err != nil {
+		return err
+	}
+	for _, w := range ss.ValidateParamSets(ps) {
+		fmt.Printf("params warning: %s: %s\n", fnm, w)
+	}
+	MergeParamSets(&ss.Params, ps)
+	fmt.Printf("Loaded params from: %s\n", fnm)
+	return nil
+}
+
+// MergeParamSets merges src sets into dst, with src values taking precedence.
+// dst is replaced with a deep copy before merging, and new sets are copied
+// from src, so neither shares anything with the other, or with the global
+// ParamSets that dst usually starts out as.
+func MergeParamSets(dst *params.Sets, src params.Sets) {
+	*dst = CopyParamSets(*dst)
+	for _, sset := range src {
+		dset, err := dst.SetByNameTry(sset.Name)
+		if err != nil {
+			*dst = append(*dst, CopyParamSet(sset))
+			continue
+		}
+		MergeParamSet(dset, sset)
+	}
+}
+
+// CopyParamSets returns a deep copy of given sets
+func CopyParamSets(ps params.Sets) params.Sets {
+	cp := make(params.Sets, len(ps))
+	for i, set := range ps {
+		cp[i] = CopyParamSet(set)
+	}
+	return cp
+}
+
+// CopyParamSet returns a deep copy of given set
+func CopyParamSet(set *params.Set) *params.Set {
+	cp := *set
+	cp.Sheets = make(params.Sheets, len(set.Sheets))
+	for snm, sht := range set.Sheets {
+		nsht := make(params.Sheet, len(*sht))
+		for i, sel := range *sht {
+			nsel := *sel
+			nsel.Params = make(params.Params, len(sel.Params))
+			for pt, pv := range sel.Params {
+				nsel.Params[pt] = pv
+			}
+			nsht[i] = &nsel
+		}
+		cp.Sheets[snm] = &nsht
+	}
+	return &cp
+}
+
+// MergeParamSet merges sheets of src set into dst, with src values taking
+// precedence.  dst is modified in place, but shares nothing with src after.
+func MergeParamSet(dst, src *params.Set) {
+	if src.Desc != "" {
+		dst.Desc = src.Desc
+	}
+	if dst.Sheets == nil {
+		dst.Sheets = make(params.Sheets)
+	}
+	for snm, ssht := range src.Sheets {
+		dsht, ok := dst.Sheets[snm]
+		if !ok {
+			dsht = &params.Sheet{}
+			dst.Sheets[snm] = dsht
+		}
+		MergeParamSheet(dsht, ssht)
+	}
+}
+
+// MergeParamSheet merges selectors of src sheet into dst: params for a
+// selector already in dst are set from src, and new selectors are added
+// at the end, so they are applied last.
+func MergeParamSheet(dst, src *params.Sheet) {
+	for _, ssel := range *src {
+		var dsel *params.Sel
+		for _, sl := range *dst {
+			if sl.Sel == ssel.Sel {
+				dsel = sl
+				break
+			}
+		}
+		if dsel == nil {
+			dsel = &params.Sel{Sel: ssel.Sel, Desc: ssel.Desc, Params: make(params.Params)}
+			*dst = append(*dst, dsel)
+		}
+		if dsel.Params == nil {
+			dsel.Params = make(params.Params)
+		}
+		for pt, pv := range ssel.Params {
+			dsel.Params[pt] = pv
+		}
+	}
+}
+
+// EffectiveParams returns a single params.Set with Base and then each of the
+// current ParamSet names merged in order, which is the full set of parameters
+// applied by SetParams.
+func (ss *Sim) EffectiveParams() *params.Set {
+	eff := &params.Set{Name: ss.ParamsName(), Desc: "effective merged parameters", Sheets: make(params.Sheets)}
+	nms := []string{"Base"}
+	if ss.ParamSet != "" && ss.ParamSet != "Base" {
+		nms = append(nms, strings.Fields(ss.ParamSet)...)
+	}
+	for _, nm := range nms {
+		pset, err := ss.Params.SetByNameTry(nm)
+		if err != nil {
+			continue
+		}
+		MergeParamSet(eff, pset)
+	}
+	return eff
+}
+
+// SaveEffectiveParams saves the EffectiveParams as JSON to given file, and
+// the resulting values of all network parameters to the same file name
+// with a .txt extension.
+func (ss *Sim) SaveEffectiveParams(fnm string) error {
+	eff := params.Sets{ss.EffectiveParams()}
+	err := eff.SaveJSON(gi.FileName(fnm))
+	if err != nil {
+		return err
+	}
+	tfnm := strings.TrimSuffix(fnm, filepath.Ext(fnm)) + ".txt"
+	err = os.WriteFile(tfnm, []byte(ss.Net.AllParams()), 0644)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Saving effective params to: %s\n", fnm)
+	return nil
+}
+
+// ValidateParamSets returns a warning for each selector in given sets that
+// does not match any layer or projection in the network, and for each
+// parameter path that does not exist on the object it applies to.
+func (ss *Sim) ValidateParamSets(ps params.Sets) []string {
+	var warns []string
+	for _, pset := range ps {
+		for snm, sht := range pset.Sheets {
+			for _, sel := range *sht {
+				loc := fmt.Sprintf("set: %s sheet: %s sel: %s", pset.Name, snm, sel.Sel)
+				switch snm {
+				case "Network":
+					if !ss.SelMatchesNet(sel.Sel) {
+						warns = append(warns, loc+": selector does not match any layer or projection")
+					}
+				case "Sim":
+				default:
+					warns = append(warns, loc+": unknown sheet")
+					continue
+				}
+				for pt := range sel.Params {
+					if !ValidParamPath(pt) {
+						warns = append(warns, loc+": unknown parameter path: "+pt)
+					}
+				}
+			}
+		}
+	}
+	return warns
+}
+
+// SelMatchesNet returns true if given params selector matches any layer or
+// projection in the network, by type (Layer, Prjn), .Class or #Name.
+func (ss *Sim) SelMatchesNet(sel string) bool {
+	switch {
+	case sel == "Layer" || sel == "Prjn":
+		return true
+	case strings.HasPrefix(sel, "#"):
+		nm := sel[1:]
+		if ss.Net.LayerByName(nm) != nil {
+			return true
+		}
+		return ss.PrjnMatches(func(cls, pnm string) bool { return pnm == nm })
+	case strings.HasPrefix(sel, "."):
+		cl := sel[1:]
+		for li := 0; li < ss.Net.NLayers(); li++ {
+			if hasClass(ss.Net.Layer(li).Class(), cl) {
+				return true
+			}
+		}
+		return ss.PrjnMatches(func(cls, pnm string) bool { return hasClass(cls, cl) })
+	}
+	return false
+}
+
+// PrjnMatches returns true if given function is true for the class and name
+// of any projection in the network.
+func (ss *Sim) PrjnMatches(fun func(cls, nm string) bool) bool {
+	for li := 0; li < ss.Net.NLayers(); li++ {
+		for _, pj := range *ss.Net.Layer(li).RecvPrjns() {
+			if fun(pj.Class(), pj.Name()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasClass returns true if space-separated class list includes given class
+func hasClass(clss, cl string) bool {
+	for _, c := range strings.Fields(clss) {
+		if c == cl {
+			return true
+		}
+	}
+	return false
+}
+
+// ParamPathTypes are the types that params paths start with, for ValidParamPath
+var ParamPathTypes = map[string]reflect.Type{
+	"Layer": reflect.TypeOf(leabra.Layer{}),
+	"Prjn":  reflect.TypeOf(leabra.Prjn{}),
+	"Sim":   reflect.TypeOf(Sim{}),
+}
+
+// ValidParamPath returns true if given params path, e.g., Layer.Inhib.Layer.Gi,
+// names an existing field of the Layer, Prjn or Sim type it starts with.
+func ValidParamPath(path string) bool {
+	flds := strings.Split(path, ".")
+	typ, ok := ParamPathTypes[flds[0]]
+	if !ok {
+		return false
+	}
+	for _, fnm := range flds[1:] {
+		if typ.Kind() == reflect.Ptr {
+			typ = typ.Elem()
+		}
+		if typ.Kind() != reflect.Struct {
+			return false
+		}
+		fld, ok := typ.FieldByName(fnm)
+		if !ok {
+			return false
+		}
+		typ = fld.Type
+	}
+	return true
+}
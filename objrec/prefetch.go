@@ -0,0 +1,162 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"image"
+	"math/rand"
+
+	"github.com/emer/etable/etensor"
+	"github.com/emer/vision/vxform"
+)
+
+// Prefetch renders and filters the upcoming stimuli of an LEDEnv in a pool
+// of background workers, into a bounded queue, so that stimulus generation
+// overlaps with network settling.  Item i of the sequence draws its object
+// and transform from its own random source, seeded from Seed and i, so the
+// sequence is the same for a given Seed regardless of timing, and does not
+// use the global random numbers.  If the object range or transform ranges of
+// the env change (e.g., with Curric or continual stages), the queue is
+// restarted from the current item with the new ranges.
+type Prefetch struct {
+	Workers   int   `desc:"number of background workers -- 0 = off, and stimuli are generated synchronously in Step"`
+	QueueSize int   `desc:"maximum number of stimuli queued ahead of the current one"`
+	Seed      int64 `inactive:"+" desc:"random seed for the sequence, set at Init from the sim random seed and run"`
+	Idx       int   `inactive:"+" desc:"index of the next stimulus in the sequence"`
+
+	params prefetchParams
+	futs   chan chan *prefetchItem
+	stop   chan struct{}
+}
+
+// prefetchParams are the env params that determine the stimuli
+type prefetchParams struct {
+	MinLED    int
+	MaxLED    int
+	XFormRand vxform.Rand
+}
+
+// prefetchItem is one prepared stimulus
+type prefetchItem struct {
+	LED   int
+	XForm vxform.XForm
+	Orig  *image.RGBA
+	Img   *etensor.Float32
+	V1    []*etensor.Float32
+}
+
+// prefetchJob is a request to prepare item Idx of the sequence into Fut
+type prefetchJob struct {
+	Idx int
+	Fut chan *prefetchItem
+}
+
+// Init stops any workers and starts a new sequence for given run, with its
+// Seed derived from given seed (e.g., the sim RndSeed) and the run.  The
+// global random numbers are not used, as that would change everything drawn
+// from them after, including the initial weights.
+func (pf *Prefetch) Init(seed int64, run int) {
+	pf.Stop()
+	rnd := rand.New(rand.NewSource(seed))
+	for r := 0; r <= run; r++ {
+		pf.Seed = rnd.Int63()
+	}
+	pf.Idx = 0
+}
+
+// Stop stops the workers, discarding any queued stimuli
+func (pf *Prefetch) Stop() {
+	if pf.stop != nil {
+		close(pf.stop)
+	}
+	pf.stop = nil
+	pf.futs = nil
+}
+
+// Start starts the workers preparing the sequence from the current Idx,
+// for given env with given params
+func (pf *Prefetch) Start(ev *LEDEnv, p prefetchParams) {
+	pf.Stop()
+	pf.params = p
+	qsz := pf.QueueSize
+	if qsz < 1 {
+		qsz = 1
+	}
+	futs := make(chan chan *prefetchItem, qsz)
+	jobs := make(chan prefetchJob)
+	stop := make(chan struct{})
+	pf.futs = futs
+	pf.stop = stop
+	for w := 0; w < pf.Workers; w++ {
+		ld := &LEDraw{Width: ev.Draw.Width, Size: ev.Draw.Size, LineColor: ev.Draw.LineColor, BgColor: ev.Draw.BgColor, ImgSize: ev.Draw.ImgSize}
+		ld.Init()
+		go prefetchWork(pf.Seed, p, ld, ev.Vis.CloneParams(), jobs)
+	}
+	go func(idx int) {
+		defer close(jobs)
+		for i := idx; ; i++ {
+			fut := make(chan *prefetchItem, 1)
+			select {
+			case futs <- fut:
+			case <-stop:
+				return
+			}
+			select {
+			case jobs <- prefetchJob{Idx: i, Fut: fut}:
+			case <-stop:
+				return
+			}
+		}
+	}(pf.Idx)
+}
+
+// prefetchWork is a worker that prepares each job, using its own LEDraw and Vis
+func prefetchWork(seed int64, p prefetchParams, ld *LEDraw, vi *Vis, jobs <-chan prefetchJob) {
+	for jb := range jobs {
+		rnd := rand.New(rand.NewSource(seed + int64(jb.Idx)))
+		it := &prefetchItem{LED: p.MinLED + rnd.Intn(1+p.MaxLED-p.MinLED)}
+		rx := &p.XFormRand
+		it.XForm.Set(rx.TransX.Min+rnd.Float32()*(rx.TransX.Max-rx.TransX.Min),
+			rx.TransY.Min+rnd.Float32()*(rx.TransY.Max-rx.TransY.Min),
+			rx.Scale.Min+rnd.Float32()*(rx.Scale.Max-rx.Scale.Min),
+			rx.Rot.Min+rnd.Float32()*(rx.Rot.Max-rx.Rot.Min))
+		ld.Clear()
+		ld.DrawLED(it.LED)
+		vi.Filter(it.XForm.Image(ld.Image))
+		it.Orig = image.NewRGBA(ld.Image.Bounds())
+		copy(it.Orig.Pix, ld.Image.Pix)
+		it.Img = vi.ImgTsr.Clone().(*etensor.Float32)
+		for sc := 0; sc < vi.NScales; sc++ {
+			it.V1 = append(it.V1, vi.Scale(sc).V1AllTsr.Clone().(*etensor.Float32))
+		}
+		jb.Fut <- it
+	}
+}
+
+// Next sets the state of given env from the next stimulus in the sequence,
+// starting the workers if not yet running, or restarting them if the object
+// or transform ranges of the env have changed.
+func (pf *Prefetch) Next(ev *LEDEnv) {
+	p := prefetchParams{MinLED: ev.MinLED, MaxLED: ev.MaxLED, XFormRand: ev.XFormRand}
+	if pf.futs == nil || p != pf.params {
+		pf.Start(ev, p)
+	}
+	it := <-<-pf.futs
+	pf.Idx++
+
+	ev.PrvLED = ev.CurLED
+	ev.CurLED = it.LED
+	ev.SetOutput(ev.CurLED)
+	ev.XForm = it.XForm
+	if ev.Draw.Image == nil {
+		ev.Draw.Init()
+	}
+	copy(ev.Draw.Image.Pix, it.Orig.Pix)
+	vi := &ev.Vis
+	vi.ImgTsr.CopyFrom(it.Img)
+	for sc, v1 := range it.V1 {
+		vi.Scale(sc).V1AllTsr.CopyFrom(v1)
+	}
+}
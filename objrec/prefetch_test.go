@@ -0,0 +1,57 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/emer/vision/vxform"
+)
+
+// TestPrefetchWorkers checks that the Prefetch stimulus sequence for a given
+// seed is the same for any number of workers, including after a change in
+// the transform ranges restarts the queue partway through.
+func TestPrefetchWorkers(t *testing.T) {
+	type stim struct {
+		LED   int
+		XForm vxform.XForm
+	}
+	const nstim, nchg = 40, 17
+	ss := &Sim{}
+	ss.New()
+	ss.Config()
+	ev := &ss.TrainEnv
+	xr := ev.XFormRand
+	seq := func(workers int) []stim {
+		ev.XFormRand = xr
+		ev.Prefetch.Workers = workers
+		ev.Prefetch.QueueSize = 8
+		ev.Init(0)
+		ev.Prefetch.Init(1, 0)
+		defer ev.Prefetch.Stop()
+		var sts []stim
+		for i := 0; i < nstim; i++ {
+			if i == nchg { // as Curric does at an epoch
+				ev.XFormRand.Scale.Set(0.9, 1)
+				ev.XFormRand.Rot.Set(-2, 2)
+			}
+			ev.Step()
+			sts = append(sts, stim{ev.CurLED, ev.XForm})
+		}
+		return sts
+	}
+	s1 := seq(1)
+	s4 := seq(4)
+	for i := range s1 {
+		if s1[i] != s4[i] {
+			t.Errorf("stimulus %d: 1 worker = %v, 4 workers = %v", i, s1[i], s4[i])
+		}
+	}
+	for i := nchg; i < nstim; i++ {
+		if sc := s1[i].XForm.Scale; sc < 0.9 || sc > 1 {
+			t.Errorf("stimulus %d: scale %g outside the changed range 0.9-1", i, sc)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/emer/emergent/actrf"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+)
+
+// SaveActRFs saves each of the ActRFNms normalized activation-based receptive
+// fields into given directory, as a PNG image tiling the receptive field of
+// each receiving unit, and a .tsv table with one row per receiving unit.
+// ActRFs must have been computed by TestAll.
+func (ss *Sim) SaveActRFs(dir string) error {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	for _, nm := range ss.ActRFNms {
+		rf := ss.ActRFs.RFByName(nm)
+		if rf == nil {
+			continue
+		}
+		lnm := strings.Split(nm, ":")[0]
+		ly := ss.Net.LayerByName(lnm)
+		if ly == nil {
+			continue
+		}
+		nr := len(ly.Shape().Shp)
+		fnm := filepath.Join(dir, strings.Replace(nm, ":", "_", -1))
+		err = SavePNG(ActRFImage(rf, nr), fnm+".png")
+		if err != nil {
+			return err
+		}
+		dt := ActRFTable(rf, nr)
+		err = dt.SaveCSV(gi.FileName(fnm+".tsv"), etable.Tab, etable.Headers)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Saved activation-based receptive fields to: %s\n", dir)
+	return nil
+}
+
+// ActRFTable returns a table with the normalized receptive field of each
+// receiving unit of given RF as a row, where the receiving layer has nr dims.
+func ActRFTable(rf *actrf.RF, nr int) *etable.Table {
+	shp := rf.NormRF.Shp.Shp
+	sshp := shp[nr:]
+	nu := 1
+	for _, d := range shp[:nr] {
+		nu *= d
+	}
+	dt := &etable.Table{}
+	dt.SetMetaData("name", rf.Name)
+	dt.SetMetaData("desc", "Normalized activation-based receptive field per receiving unit")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+	sch := etable.Schema{
+		{"Unit", etensor.INT64, nil, nil},
+		{"RF", etensor.FLOAT32, sshp, nil},
+	}
+	dt.SetFromSchema(sch, nu)
+	ns := rf.NormRF.Len() / nu
+	for ui := 0; ui < nu; ui++ {
+		dt.SetCellFloat("Unit", ui, float64(ui))
+		ct := dt.CellTensor("RF", ui).(*etensor.Float32)
+		copy(ct.Values, rf.NormRF.Values[ui*ns:(ui+1)*ns])
+	}
+	return dt
+}
+
+// ActRFImage returns a greyscale image tiling the normalized receptive field
+// of each receiving unit of given RF (where the receiving layer has nr dims),
+// laid out as the units are in the receiving layer, with a 1 pixel border
+// between units.  Both levels are drawn bottom-zero, as in the NetView.
+func ActRFImage(rf *actrf.RF, nr int) *image.Gray {
+	shp := rf.NormRF.Shp.Shp
+	rshp := shp[:nr]
+	sshp := shp[nr:]
+	rny, rnx := gridSize(rshp)
+	sny, snx := gridSize(sshp)
+	ty := sny + 1
+	tx := snx + 1
+	img := image.NewGray(image.Rect(0, 0, rnx*tx+1, rny*ty+1))
+	for i := range img.Pix {
+		img.Pix[i] = 128 // border
+	}
+	var rs, srs etensor.Shape
+	rs.SetShape(rshp, nil, nil)
+	srs.SetShape(sshp, nil, nil)
+	min, max := float32(0), float32(0)
+	for i, v := range rf.NormRF.Values {
+		if i == 0 || v < min {
+			min = v
+		}
+		if i == 0 || v > max {
+			max = v
+		}
+	}
+	rng := max - min
+	nu := rs.Len()
+	ns := srs.Len()
+	for ui := 0; ui < nu; ui++ {
+		ry, rx := gridPos(rs.Index(ui), rshp)
+		oy := (rny-1-ry)*ty + 1
+		ox := rx*tx + 1
+		for si := 0; si < ns; si++ {
+			sy, sx := gridPos(srs.Index(si), sshp)
+			v := float32(0)
+			if rng > 0 {
+				v = (rf.NormRF.Values[ui*ns+si] - min) / rng
+			}
+			img.SetGray(ox+sx, oy+(sny-1-sy), color.Gray{Y: uint8(v * 255)})
+		}
+	}
+	return img
+}
+
+// gridSize returns the 2D display size of given 1, 2 or 4D shape,
+// where 4D shapes are pools of units.
+func gridSize(shp []int) (ny, nx int) {
+	switch len(shp) {
+	case 4:
+		return shp[0] * shp[2], shp[1] * shp[3]
+	case 2:
+		return shp[0], shp[1]
+	case 1:
+		return 1, shp[0]
+	}
+	return 0, 0
+}
+
+// gridPos returns the 2D display position of given index into 1, 2 or 4D shape
+func gridPos(idx []int, shp []int) (y, x int) {
+	switch len(shp) {
+	case 4:
+		return idx[0]*shp[2] + idx[2], idx[1]*shp[3] + idx[3]
+	case 2:
+		return idx[0], idx[1]
+	case 1:
+		return 0, idx[0]
+	}
+	return 0, 0
+}
+
+// CmdActRFs runs TestAll on each of given weights files, and saves the
+// resulting activation-based receptive fields into a subdirectory of dir
+// named after the weights file.
+func (ss *Sim) CmdActRFs(dir string, wtsFiles []string) {
+	if len(wtsFiles) == 0 {
+		if !ss.RunFromTrained {
+			fmt.Printf("actrfs: no weights files given\n")
+			return
+		}
+		wtsFiles = []string{ss.TrainedWts}
+	}
+	for _, wf := range wtsFiles {
+		err := ss.OpenWts(wf)
+		if err != nil {
+			fmt.Printf("actrfs: %v\n", err)
+			continue
+		}
+		fmt.Printf("Computing activation-based receptive fields for: %s\n", wf)
+		ss.TestAll()
+		err = ss.SaveActRFs(ss.OutFileName(filepath.Join(dir, WtsFileBase(wf))))
+		if err != nil {
+			fmt.Printf("actrfs: %v\n", err)
+		}
+	}
+}
+
+// WtsFileBase returns the base name of given weights file, without
+// directory or .wts, .wts.gz extension
+func WtsFileBase(fnm string) string {
+	fnm = filepath.Base(fnm)
+	fnm = strings.TrimSuffix(fnm, ".gz")
+	return strings.TrimSuffix(fnm, ".wts")
+}
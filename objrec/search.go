@@ -0,0 +1,243 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/emer/emergent/params"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/goki/gi/gi"
+)
+
+// SearchSpec specifies a hyperparameter search over the ranges (Min, Max)
+// or value lists (Vals) of the Params.  Method random trains each of Trials
+// random configurations for MaxEpcs.  Method halving (successive halving)
+// trains them all for a small number of epochs, keeps the best 1/Eta, and
+// continues training those from where they left off up to Eta times as many
+// epochs, over Rungs rungs ending at MaxEpcs.
+type SearchSpec struct {
+	Method    string       `desc:"random or halving"`
+	Objective string       `desc:"what to optimize: TstPctCor (testing accuracy after training, the default) or FirstZero (fewest epochs to zero training errors)"`
+	Trials    int          `desc:"number of random configurations to try"`
+	Rungs     int          `desc:"for halving, number of rungs of increasing epochs (default 3)"`
+	Eta       int          `desc:"for halving, factor by which configurations are reduced and epochs increased at each rung (default 3)"`
+	Seed      int64        `desc:"random seed for sampling configurations"`
+	Params    []SweepParam `desc:"parameters to search"`
+}
+
+// OpenSearchSpec opens a SearchSpec from given JSON file, e.g.:
+//
+//	{"Method": "halving", "Trials": 27, "Params": [
+//		{"Sel": ".Back", "Path": "Prjn.WtScale.Rel", "Min": 0.05, "Max": 0.5, "Log": true},
+//		{"Sel": "#IT", "Path": "Layer.Inhib.Layer.Gi", "Min": 1.6, "Max": 2.4}]}
+func OpenSearchSpec(fnm string) (*SearchSpec, error) {
+	b, err := os.ReadFile(fnm)
+	if err != nil {
+		return nil, err
+	}
+	sp := &SearchSpec{Method: "random", Objective: "TstPctCor", Trials: 10, Rungs: 3, Eta: 3, Seed: 1}
+	err = json.Unmarshal(b, sp)
+	if err != nil {
+		return nil, fmt.Errorf("search spec: %s: %v", fnm, err)
+	}
+	switch {
+	case sp.Method != "random" && sp.Method != "halving":
+		return nil, fmt.Errorf("search spec: %s: Method %q must be random or halving", fnm, sp.Method)
+	case sp.Objective != "TstPctCor" && sp.Objective != "FirstZero":
+		return nil, fmt.Errorf("search spec: %s: Objective %q must be TstPctCor or FirstZero", fnm, sp.Objective)
+	case sp.Trials <= 0 || sp.Rungs <= 0 || sp.Eta < 2:
+		return nil, fmt.Errorf("search spec: %s: Trials and Rungs must be > 0, and Eta >= 2", fnm)
+	}
+	for i := range sp.Params {
+		p := &sp.Params[i]
+		if p.Sheet == "" {
+			p.Sheet = "Network"
+		}
+		if p.Sel == "" || p.Path == "" || (len(p.Vals) == 0 && p.Max <= p.Min) || (p.Log && p.Min <= 0) {
+			return nil, fmt.Errorf("search spec: %s: param %d needs Sel, Path, and Vals or Max > Min (> 0 if Log)", fnm, i)
+		}
+	}
+	return sp, nil
+}
+
+// Sample returns a random value for given param, from its Vals if set,
+// otherwise from its range.
+func (sp *SweepParam) Sample(rnd *rand.Rand) string {
+	if len(sp.Vals) > 0 {
+		return sp.Vals[rnd.Intn(len(sp.Vals))]
+	}
+	if sp.Log {
+		lmin, lmax := math.Log(sp.Min), math.Log(sp.Max)
+		return strconv.FormatFloat(math.Exp(lmin+rnd.Float64()*(lmax-lmin)), 'g', 4, 64)
+	}
+	return strconv.FormatFloat(sp.Min+rnd.Float64()*(sp.Max-sp.Min), 'g', 4, 64)
+}
+
+// Budgets returns the number of epochs to train at each rung, given the
+// full number of epochs
+func (sp *SearchSpec) Budgets(maxEpcs int) []int {
+	if sp.Method == "random" {
+		return []int{maxEpcs}
+	}
+	bs := make([]int, sp.Rungs)
+	for r := range bs {
+		b := float64(maxEpcs) / math.Pow(float64(sp.Eta), float64(sp.Rungs-1-r))
+		bs[r] = int(math.Max(1, math.Round(b)))
+	}
+	return bs
+}
+
+// ObjectiveVal returns the value of the Objective for given score, as
+// higher-is-better -- FirstZero is negated, and runs that never reached zero
+// errors count as one more than their number of epochs.
+func (sp *SearchSpec) ObjectiveVal(sc *RunScore) float64 {
+	if sp.Objective == "FirstZero" {
+		if sc.FirstZero < 0 {
+			return -float64(sc.Epochs + 1)
+		}
+		return -float64(sc.FirstZero)
+	}
+	return sc.TstPctCor
+}
+
+// searchTrial is one configuration in a search
+type searchTrial struct {
+	idx  int
+	vals []string
+	obj  float64
+	st   *ScoredState // for halving, state to resume from at the next rung
+}
+
+// CmdSearch runs the hyperparameter search in given spec file, testing on
+// given split of objects, and saves every trial to the search log, and the
+// best configuration as a params.Set named Best, to a params JSON file that
+// can be used with -paramsfile and -params Best.  The Best set has the best
+// values merged over all of the current effective params, so it stands alone.
+func (ss *Sim) CmdSearch(fnm, split string) {
+	sp, err := OpenSearchSpec(fnm)
+	if err != nil {
+		fmt.Printf("search: %v\n", err)
+		return
+	}
+	maxEpcs := ss.MaxEpcs
+	defer func() { ss.MaxEpcs = maxEpcs }()
+	rnd := rand.New(rand.NewSource(sp.Seed))
+	trls := make([]*searchTrial, sp.Trials)
+	for i := range trls {
+		vals := make([]string, len(sp.Params))
+		for pi := range sp.Params {
+			vals[pi] = sp.Params[pi].Sample(rnd)
+		}
+		trls[i] = &searchTrial{idx: i, vals: vals}
+		if sp.Method == "halving" {
+			trls[i].st = &ScoredState{Wts: ss.OutFileName(fmt.Sprintf("%s_%s_search%d.wts.gz", ss.Net.Nm, ss.RunName(), i))}
+		}
+	}
+	defer func() { // remove resume weights of those still left
+		for _, tr := range trls {
+			tr.RemoveWts()
+		}
+	}()
+	dt := &etable.Table{}
+	ss.ConfigSearchLog(dt, sp)
+	lfnm := ss.LogFileName("search")
+	bgts := sp.Budgets(ss.MaxEpcs)
+	seed := ss.RndSeed
+	for r, bgt := range bgts {
+		fmt.Printf("Search rung: %d\tConfigs: %d\tEpochs: %d\n", r, len(trls), bgt)
+		for _, tr := range trls {
+			ss.MaxEpcs = bgt
+			sc := ss.TrainScoredFrom(SweepParamSet("Search", sp.Params, tr.vals), seed, split, tr.st)
+			if ss.Interrupted {
+				fmt.Printf("search: interrupted -- results so far are in: %s\n", lfnm)
+				return
+			}
+			tr.obj = sp.ObjectiveVal(&sc)
+			row := dt.Rows
+			dt.SetNumRows(row + 1)
+			dt.SetCellFloat("Trial", row, float64(tr.idx))
+			dt.SetCellFloat("Rung", row, float64(r))
+			dt.SetCellFloat("Budget", row, float64(bgt))
+			dt.SetCellFloat("Seed", row, float64(seed))
+			for pi, p := range sp.Params {
+				dt.SetCellString(p.Name(), row, tr.vals[pi])
+			}
+			SetScore(dt, row, &sc)
+			dt.SetCellFloat("Objective", row, tr.obj)
+			fmt.Printf("Trial: %d\t%s\t%s: %g\n", tr.idx, strings.Join(tr.vals, " "), sp.Objective, tr.obj)
+			dt.SaveCSV(gi.FileName(lfnm), etable.Tab, etable.Headers) // save as we go
+		}
+		sort.SliceStable(trls, func(i, j int) bool {
+			return trls[i].obj > trls[j].obj
+		})
+		if r < len(bgts)-1 {
+			nkeep := (len(trls) + sp.Eta - 1) / sp.Eta
+			for _, tr := range trls[nkeep:] {
+				tr.RemoveWts()
+			}
+			trls = trls[:nkeep]
+		}
+	}
+	fmt.Printf("Saved search results to: %s\n", lfnm)
+
+	best := trls[0]
+	set := ss.EffectiveParams()
+	set.Name = "Best"
+	MergeParamSet(set, SweepParamSet("Best", sp.Params, best.vals))
+	set.Desc = fmt.Sprintf("best of %s search, trial %d: %s = %g", sp.Method, best.idx, sp.Objective, best.obj)
+	b, err := json.MarshalIndent(params.Sets{set}, "", "  ")
+	if err != nil {
+		fmt.Printf("search: %v\n", err)
+		return
+	}
+	bfnm := ss.OutFileName(ss.Net.Nm + "_" + ss.RunName() + "_best_params.json")
+	err = os.WriteFile(bfnm, b, 0644)
+	if err != nil {
+		fmt.Printf("search: %v\n", err)
+		return
+	}
+	fmt.Printf("Best: %s\nSaved best params to: %s -- use with: -paramsfile %s -params Best\n", set.Desc, bfnm, bfnm)
+}
+
+// RemoveWts removes the saved resume weights of the trial, if any
+func (tr *searchTrial) RemoveWts() {
+	if tr.st == nil || tr.st.Epoch == 0 {
+		return
+	}
+	err := os.Remove(tr.st.Wts)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("search: %v\n", err)
+	}
+	tr.st.Epoch = 0
+}
+
+func (ss *Sim) ConfigSearchLog(dt *etable.Table, sp *SearchSpec) {
+	dt.SetMetaData("name", "SearchLog")
+	dt.SetMetaData("desc", "Results of each trial of a hyperparameter search")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Trial", etensor.INT64, nil, nil},
+		{"Rung", etensor.INT64, nil, nil},
+		{"Budget", etensor.INT64, nil, nil},
+		{"Seed", etensor.INT64, nil, nil},
+	}
+	for _, p := range sp.Params {
+		sch = append(sch, etable.Column{p.Name(), etensor.STRING, nil, nil})
+	}
+	sch = append(sch, ScoreSchema()...)
+	sch = append(sch, etable.Column{"Objective", etensor.FLOAT64, nil, nil})
+	dt.SetFromSchema(sch, 0)
+}
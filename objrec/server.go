@@ -0,0 +1,349 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// SimState is the current counters and stats of the sim, as reported by
+// the /state endpoint of the control server
+type SimState struct {
+	Run           int     `desc:"current run"`
+	Epoch         int     `desc:"current training epoch"`
+	Trial         int     `desc:"current training trial"`
+	TestTrial     int     `desc:"current testing trial"`
+	Cycle         int     `desc:"current cycle"`
+	TrialName     string  `desc:"current training trial name"`
+	IsRunning     bool    `desc:"true if a long-running action is in progress"`
+	TrlErr        float64 `desc:"last trial's error"`
+	TrlSSE        float64 `desc:"last trial's sum squared error"`
+	TrlCosDiff    float64 `desc:"last trial's cosine difference"`
+	TrlRT         float64 `desc:"last test trial's reaction time"`
+	EpcPctErr     float64 `desc:"last epoch's proportion of errors"`
+	EpcPctCor     float64 `desc:"last epoch's proportion correct"`
+	EpcCosDiff    float64 `desc:"last epoch's cosine difference"`
+	EpcPerTrlMSec float64 `desc:"last epoch's time per trial in msec"`
+	FirstZero     int     `desc:"epoch at which error first went to zero"`
+	NZero         int     `desc:"number of epochs in a row with zero error"`
+}
+
+// State returns the current SimState -- only to be called from the
+// goroutine running the sim, as it reads the live Sim fields
+func (ss *Sim) State() *SimState {
+	return &SimState{
+		Run:           ss.TrainEnv.Run.Cur,
+		Epoch:         ss.TrainEnv.Epoch.Cur,
+		Trial:         ss.TrainEnv.Trial.Cur,
+		TestTrial:     ss.TestEnv.Trial.Cur,
+		Cycle:         ss.Time.Cycle,
+		TrialName:     ss.TrainEnv.String(),
+		TrlErr:        ss.TrlErr,
+		TrlSSE:        ss.TrlSSE,
+		TrlCosDiff:    ss.TrlCosDiff,
+		TrlRT:         ss.TrlRT,
+		EpcPctErr:     ss.EpcPctErr,
+		EpcPctCor:     ss.EpcPctCor,
+		EpcCosDiff:    ss.EpcCosDiff,
+		EpcPerTrlMSec: ss.EpcPerTrlMSec,
+		FirstZero:     ss.FirstZero,
+		NZero:         ss.NZero,
+	}
+}
+
+// LogByName returns the log table of given name, nil if not found
+func (ss *Sim) LogByName(name string) *etable.Table {
+	switch name {
+	case "TrnEpcLog":
+		return ss.TrnEpcLog
+	case "TstEpcLog":
+		return ss.TstEpcLog
+	case "TstHistLog":
+		return ss.TstHistLog
+	case "TstTrlLog":
+		return ss.TstTrlLog
+	case "TstActLog":
+		return ss.TstActLog
+	case "TstCycLog":
+		return ss.TstCycLog
+	case "GenLog":
+		return ss.GenLog
+	case "InterfLog":
+		return ss.InterfLog
+	case "ContLog":
+		return ss.ContLog
+	case "RunLog":
+		return ss.RunLog
+	case "RunStats":
+		return ss.RunStats
+	}
+	return nil
+}
+
+// LogNames are the names of the logs available from LogByName
+var LogNames = []string{"TrnEpcLog", "TstEpcLog", "TstHistLog", "TstTrlLog", "TstActLog", "TstCycLog", "GenLog", "InterfLog", "ContLog", "RunLog", "RunStats"}
+
+// JSONFloat returns given value for encoding as JSON, which has no NaN or
+// Inf -- those are returned as nil, encoded as null.
+func JSONFloat(v float64) interface{} {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return nil
+	}
+	return v
+}
+
+// TableRowsJSON returns the rows of given table starting at row st as a slice
+// of maps from column name to value, with tensor cells as flat slices of values.
+// NaN and Inf values are nil (see JSONFloat).
+func TableRowsJSON(dt *etable.Table, st int) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, dt.Rows-st)
+	for ri := st; ri < dt.Rows; ri++ {
+		row := make(map[string]interface{}, len(dt.Cols))
+		for ci, cl := range dt.Cols {
+			nm := dt.ColNames[ci]
+			switch {
+			case cl.DataType() == etensor.STRING:
+				row[nm] = cl.StringVal1D(ri)
+			case cl.NumDims() == 1:
+				row[nm] = JSONFloat(cl.FloatVal1D(ri))
+			default:
+				ct := dt.CellTensor(nm, ri)
+				vals := make([]interface{}, ct.Len())
+				for i := range vals {
+					vals[i] = JSONFloat(ct.FloatVal1D(i))
+				}
+				row[nm] = vals
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// SimServer is a local HTTP/JSON server for controlling the sim without
+// the gui: running, stepping and testing, and querying state and logs.
+// The handlers never read the live Sim, which is being updated by the
+// goroutine running it: that goroutine publishes a snapshot of the State
+// at the end of each trial, and of the logs at the end of each epoch and
+// test, and the handlers only serve those snapshots.
+type SimServer struct {
+	Sim     *Sim                     `desc:"the sim being controlled"`
+	Mu      sync.Mutex               `desc:"protects the snapshots, StopReq and Sim.IsRunning"`
+	State   SimState                 `desc:"snapshot of the sim state, as of the last Publish"`
+	Logs    map[string]*etable.Table `desc:"snapshots of the LogNames logs, as of the last Publish with logs -- these are never modified once published"`
+	StopReq bool                     `desc:"set by /stop -- the sim is stopped at the next Publish"`
+}
+
+// NewServer returns a new control server for the sim, with initial
+// snapshots published -- must be called before the sim starts running in
+// another goroutine.  Sets ss.Server so the sim publishes to it.
+func (ss *Sim) NewServer() *SimServer {
+	sv := &SimServer{Sim: ss}
+	ss.Server = sv
+	sv.Publish(true)
+	return sv
+}
+
+// Serve starts a new control server on given address, which should be a
+// localhost address -- blocks until the server fails.
+func (ss *Sim) Serve(addr string) error {
+	return ss.NewServer().ListenAndServe(addr)
+}
+
+// ListenAndServe serves the control API on given address -- blocks until
+// the server fails.
+func (sv *SimServer) ListenAndServe(addr string) error {
+	mux := http.NewServeMux()
+	sv.Routes(mux)
+	fmt.Printf("Serving sim control API on: http://%s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// Publish updates the snapshot of the State, and of the logs if logs is
+// true, and stops the sim if a stop was requested.  Must only be called
+// from the goroutine running the sim.
+func (sv *SimServer) Publish(logs bool) {
+	ss := sv.Sim
+	st := ss.State()
+	var lgs map[string]*etable.Table
+	if logs {
+		lgs = make(map[string]*etable.Table, len(LogNames))
+		for _, nm := range LogNames {
+			lgs[nm] = ss.LogByName(nm).Clone()
+		}
+	}
+	sv.Mu.Lock()
+	defer sv.Mu.Unlock()
+	st.IsRunning = ss.IsRunning
+	sv.State = *st
+	if logs {
+		sv.Logs = lgs
+	}
+	if sv.StopReq {
+		sv.StopReq = false
+		ss.StopNow = true
+	}
+}
+
+// Snapshot returns the current snapshot of the State
+func (sv *SimServer) Snapshot() SimState {
+	sv.Mu.Lock()
+	defer sv.Mu.Unlock()
+	return sv.State
+}
+
+// LogSnapshot returns the current snapshot of the log of given name, nil
+// if not found.  The table must not be modified.
+func (sv *SimServer) LogSnapshot(name string) *etable.Table {
+	sv.Mu.Lock()
+	defer sv.Mu.Unlock()
+	return sv.Logs[name]
+}
+
+// Publish publishes a snapshot of the State to the control server, if
+// serving, and of the logs if logs is true -- see SimServer.Publish
+func (ss *Sim) Publish(logs bool) {
+	if ss.Server != nil {
+		ss.Server.Publish(logs)
+	}
+}
+
+// SetRunning sets IsRunning, under the control server lock if serving
+func (ss *Sim) SetRunning(on bool) {
+	if sv := ss.Server; sv != nil {
+		sv.Mu.Lock()
+		defer sv.Mu.Unlock()
+	}
+	ss.IsRunning = on
+}
+
+// Routes adds the control API endpoints to given mux
+func (sv *SimServer) Routes(mux *http.ServeMux) {
+	ss := sv.Sim
+	mux.HandleFunc("/init", sv.Action(false, ss.Init))
+	mux.HandleFunc("/train", sv.Action(true, ss.Train))
+	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
+		if !RequirePost(w, r) {
+			return
+		}
+		sv.Mu.Lock()
+		if sv.Sim.IsRunning {
+			sv.StopReq = true
+		}
+		st := sv.State
+		sv.Mu.Unlock()
+		WriteJSON(w, st)
+	})
+	mux.HandleFunc("/step/trial", sv.Action(false, ss.TrainTrial))
+	mux.HandleFunc("/step/epoch", sv.Action(true, ss.TrainEpoch))
+	mux.HandleFunc("/step/run", sv.Action(true, ss.TrainRun))
+	mux.HandleFunc("/test/all", sv.Action(true, ss.RunTestAll))
+	mux.HandleFunc("/test/item", func(w http.ResponseWriter, r *http.Request) {
+		idx, err := strconv.Atoi(r.FormValue("idx"))
+		if err != nil {
+			http.Error(w, "test/item requires integer idx", http.StatusBadRequest)
+			return
+		}
+		sv.Action(false, func() { ss.TestItem(idx) })(w, r)
+	})
+	mux.HandleFunc("/state", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, sv.Snapshot())
+	})
+	mux.HandleFunc("/logs", func(w http.ResponseWriter, r *http.Request) {
+		WriteJSON(w, LogNames)
+	})
+	mux.HandleFunc("/logs/", sv.Log)
+	mux.HandleFunc("/events", sv.Events)
+	mux.HandleFunc("/", sv.Dashboard)
+}
+
+// Action returns a handler that runs given function, if not already running.
+// If async, the function runs in the background, as for the gui Train
+// button, and the handler returns immediately; otherwise the handler returns
+// when it is done.  Responds with the resulting State snapshot.  The
+// goroutine running the function is the one running the sim until it is
+// done, and publishes the final snapshots.
+func (sv *SimServer) Action(async bool, fun func()) http.HandlerFunc {
+	ss := sv.Sim
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !RequirePost(w, r) {
+			return
+		}
+		sv.Mu.Lock()
+		if ss.IsRunning {
+			sv.Mu.Unlock()
+			http.Error(w, "sim is already running -- use /stop first", http.StatusConflict)
+			return
+		}
+		ss.IsRunning = true
+		sv.StopReq = false
+		sv.Mu.Unlock()
+		if async {
+			go func() {
+				fun()
+				ss.SetRunning(false)
+				sv.Publish(true)
+			}()
+		} else {
+			fun()
+			ss.SetRunning(false)
+			sv.Publish(true)
+		}
+		WriteJSON(w, sv.Snapshot())
+	}
+}
+
+// Log handles /logs/<name>, returning the snapshot of the named log table
+// as JSON rows, or as CSV if format=csv
+func (sv *SimServer) Log(w http.ResponseWriter, r *http.Request) {
+	nm := strings.TrimPrefix(r.URL.Path, "/logs/")
+	dt := sv.LogSnapshot(nm)
+	if dt == nil {
+		http.Error(w, fmt.Sprintf("log: %s not found -- one of: %v", nm, LogNames), http.StatusNotFound)
+		return
+	}
+	if r.FormValue("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		err := dt.WriteCSV(w, etable.Comma, etable.Headers)
+		if err != nil {
+			log.Println(err)
+		}
+		return
+	}
+	WriteJSON(w, TableRowsJSON(dt, 0))
+}
+
+// RequirePost returns true if request is a POST, and otherwise responds
+// with an error
+func RequirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	http.Error(w, "use POST for actions", http.StatusMethodNotAllowed)
+	return false
+}
+
+// WriteJSON writes given value as JSON response, or an error response if
+// it cannot be encoded
+func WriteJSON(w http.ResponseWriter, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(b, '\n'))
+}
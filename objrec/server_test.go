@@ -0,0 +1,153 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+)
+
+// TestServeTrain trains through the control server while querying the
+// state and logs -- run with -race to check that the handlers only read
+// the published snapshots.
+func TestServeTrain(t *testing.T) {
+	if testing.Short() {
+		t.Skip("trains the network")
+	}
+	ss := &Sim{}
+	ss.New()
+	ss.MaxRuns = 1
+	ss.MaxEpcs = 2
+	ss.MaxTrls = 10
+	ss.Config()
+	ss.Init()
+	sv := ss.NewServer()
+	mux := http.NewServeMux()
+	sv.Routes(mux)
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	post := func(path string) int {
+		resp, err := http.Post(ts.URL+path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	get := func(path string, v interface{}) {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: status %d", path, resp.StatusCode)
+		}
+		if v == nil {
+			io.Copy(io.Discard, resp.Body)
+			return
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+
+	if st := post("/train"); st != http.StatusOK {
+		t.Fatalf("/train: status %d", st)
+	}
+	if st := post("/init"); st != http.StatusConflict {
+		t.Errorf("/init while training: status %d, want %d", st, http.StatusConflict)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	evdone := make(chan struct{})
+	go func() { // dashboard event stream, read until training is done
+		defer close(evdone)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	deadline := time.Now().Add(5 * time.Minute)
+	for {
+		var st SimState
+		get("/state", &st)
+		var rows []map[string]interface{}
+		get("/logs/TrnEpcLog", &rows)
+		get("/logs/TstEpcLog?format=csv", nil)
+		if !st.IsRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("training did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-evdone
+
+	var rows []map[string]interface{}
+	get("/logs/TrnEpcLog", &rows)
+	if len(rows) != ss.MaxEpcs {
+		t.Errorf("TrnEpcLog snapshot has %d rows, want %d", len(rows), ss.MaxEpcs)
+	}
+	if st := post("/step/trial"); st != http.StatusOK {
+		t.Errorf("/step/trial after training: status %d", st)
+	}
+}
+
+// TestTableRowsJSONNaN checks that NaN and Inf values in a log, e.g., stats
+// of a test that has not run, are encoded as null instead of failing.
+func TestTableRowsJSONNaN(t *testing.T) {
+	dt := &etable.Table{}
+	dt.SetFromSchema(etable.Schema{
+		{"Epoch", etensor.INT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+		{"Acts", etensor.FLOAT32, []int{3}, nil},
+	}, 2)
+	dt.SetCellFloat("Epoch", 0, 0)
+	dt.SetCellFloat("PctCor", 0, math.NaN())
+	dt.SetCellTensorFloat1D("Acts", 0, 0, 0.5)
+	dt.SetCellTensorFloat1D("Acts", 0, 1, math.Inf(1))
+	dt.SetCellTensorFloat1D("Acts", 0, 2, math.NaN())
+	dt.SetCellFloat("Epoch", 1, 1)
+	dt.SetCellFloat("PctCor", 1, 0.75)
+
+	rec := httptest.NewRecorder()
+	WriteJSON(rec, TableRowsJSON(dt, 0))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	var rows []map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &rows); err != nil {
+		t.Fatalf("%v: %s", err, rec.Body.String())
+	}
+	if len(rows) != 2 {
+		t.Fatalf("%d rows, want 2", len(rows))
+	}
+	if v, has := rows[0]["PctCor"]; !has || v != nil {
+		t.Errorf("NaN PctCor = %v, want null", v)
+	}
+	if v := rows[1]["PctCor"]; v != 0.75 {
+		t.Errorf("PctCor = %v, want 0.75", v)
+	}
+	acts, _ := rows[0]["Acts"].([]interface{})
+	if len(acts) != 3 || acts[0] != 0.5 || acts[1] != nil || acts[2] != nil {
+		t.Errorf("Acts = %v, want [0.5 <nil> <nil>]", rows[0]["Acts"])
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/goki/gi/gi"
+)
+
+// HandleSignals arranges for the first SIGINT or SIGTERM to stop a
+// command-line run gracefully, at the end of the current trial, so that
+// CmdArgs can save weights and close the log files.  A second signal
+// quits immediately without saving weights, after finalizing the manifest.
+func (ss *Sim) HandleSignals() {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigs
+		fmt.Printf("\n%v: stopping after the current trial -- send again to quit immediately without saving\n", sig)
+		ss.Interrupted = true
+		ss.Stop()
+		sig = <-sigs
+		fmt.Printf("\n%v: quitting now\n", sig)
+		ss.Manifest.Interrupted = true
+		ss.EndManifest()
+		os.Exit(1)
+	}()
+}
+
+// InterruptEnd is called when a command-line run has stopped due to
+// HandleSignals -- saves the current weights, which can be used to continue
+// with -initwts, and records the interruption in the manifest.  Log files
+// are closed by CmdArgs on return.
+func (ss *Sim) InterruptEnd() {
+	ss.Manifest.Interrupted = true
+	fnm := ss.WeightsFileName()
+	err := ss.Net.SaveWtsJSON(gi.FileName(fnm))
+	if err != nil {
+		fmt.Printf("Interrupted at Run: %d Epoch: %d Trial: %d -- error saving weights: %v\n", ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur, ss.TrainEnv.Trial.Cur, err)
+		return
+	}
+	fmt.Printf("Interrupted at Run: %d Epoch: %d Trial: %d -- saved weights to: %s\n", ss.TrainEnv.Run.Cur, ss.TrainEnv.Epoch.Cur, ss.TrainEnv.Trial.Cur, fnm)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// CheckStop checks the stopping rules at the start of given epoch, in order:
+// MaxEpcs, NZeroStop, MaxMins, TargCosDiff, TstPlateau, and returns the name
+// of the first one that says to stop the run, or "" to keep going.
+func (ss *Sim) CheckStop(epc int) string {
+	switch {
+	case epc >= ss.MaxEpcs:
+		return "MaxEpcs"
+	case ss.NZeroStop > 0 && ss.NZero >= ss.NZeroStop:
+		return "NZeroStop"
+	case ss.MaxMins > 0 && time.Since(ss.RunStartTime).Minutes() >= ss.MaxMins:
+		return "MaxMins"
+	case ss.TargCosDiff > 0 && ss.EpcCosDiff >= ss.TargCosDiff:
+		return "TargCosDiff"
+	case ss.TstPlateau > 0 && ss.TstPlateaued(epc):
+		return "TstPlateau"
+	}
+	return ""
+}
+
+// TstPlateaued returns true if the best testing PctCor in the TstHistLog
+// over the last TstPlateau epochs before given epoch is no more than
+// TstPlateauTol better than the best before that.  Returns false until
+// there are tests in both periods.
+func (ss *Sim) TstPlateaued(epc int) bool {
+	dt := ss.TstHistLog
+	st := epc - ss.TstPlateau
+	prv, cur := math.Inf(-1), math.Inf(-1)
+	for ri := 0; ri < dt.Rows; ri++ {
+		pc := dt.CellFloat("PctCor", ri)
+		if int(dt.CellFloat("Epoch", ri)) < st {
+			prv = math.Max(prv, pc)
+		} else {
+			cur = math.Max(cur, pc)
+		}
+	}
+	if math.IsInf(prv, -1) || math.IsInf(cur, -1) {
+		return false
+	}
+	return cur-prv <= ss.TstPlateauTol
+}
@@ -0,0 +1,326 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/emer/emergent/params"
+	"github.com/emer/etable/etable"
+	"github.com/emer/etable/etensor"
+	"github.com/emer/etable/split"
+	"github.com/goki/gi/gi"
+)
+
+// SweepParam is one parameter to sweep over a list of values
+type SweepParam struct {
+	Sheet string   `desc:"params sheet: Network (default) or Sim"`
+	Sel   string   `desc:"params selector, e.g., .Back or #IT -- Sim for the Sim sheet"`
+	Path  string   `desc:"params path, e.g., Prjn.WtScale.Rel"`
+	Vals  []string `desc:"values to sweep over -- for search, values to choose from at random, if set instead of Min, Max"`
+	Min   float64  `desc:"for search, minimum of range to sample values from"`
+	Max   float64  `desc:"for search, maximum of range to sample values from"`
+	Log   bool     `desc:"for search, sample values uniformly on a log scale -- Min must be > 0"`
+}
+
+// Name returns the name of the param, as its selector and path
+func (sp *SweepParam) Name() string {
+	return sp.Sel + " " + sp.Path
+}
+
+// SweepSpec specifies a parameter sweep: every combination of the values
+// of the Params is trained for Seeds runs.
+type SweepSpec struct {
+	Seeds  int          `desc:"number of runs, each from a different random seed, for each combination -- 0 = -runs"`
+	Params []SweepParam `desc:"parameters to sweep"`
+}
+
+// OpenSweepSpec opens a SweepSpec from given JSON file, e.g.:
+//
+//	{"Seeds": 5, "Params": [
+//		{"Sel": ".Back", "Path": "Prjn.WtScale.Rel", "Vals": ["0.1", "0.2"]},
+//		{"Sel": "#IT", "Path": "Layer.Inhib.Layer.Gi", "Vals": ["1.8", "2.0", "2.2"]}]}
+func OpenSweepSpec(fnm string) (*SweepSpec, error) {
+	b, err := os.ReadFile(fnm)
+	if err != nil {
+		return nil, err
+	}
+	sp := &SweepSpec{}
+	err = json.Unmarshal(b, sp)
+	if err != nil {
+		return nil, fmt.Errorf("sweep spec: %s: %v", fnm, err)
+	}
+	for i := range sp.Params {
+		p := &sp.Params[i]
+		if p.Sheet == "" {
+			p.Sheet = "Network"
+		}
+		if p.Sel == "" || p.Path == "" || len(p.Vals) == 0 {
+			return nil, fmt.Errorf("sweep spec: %s: param %d needs Sel, Path and Vals", fnm, i)
+		}
+	}
+	return sp, nil
+}
+
+// Cells returns every combination of the param values, with the last param
+// varying fastest.
+func (sp *SweepSpec) Cells() [][]string {
+	cells := [][]string{{}}
+	for _, p := range sp.Params {
+		var nc [][]string
+		for _, c := range cells {
+			for _, v := range p.Vals {
+				nc = append(nc, append(append([]string{}, c...), v))
+			}
+		}
+		cells = nc
+	}
+	return cells
+}
+
+// SweepParamSet returns a params.Set of given name that sets given params
+// to given values, which are listed in its Desc
+func SweepParamSet(name string, ps []SweepParam, vals []string) *params.Set {
+	nvs := make([]string, len(ps))
+	for i, p := range ps {
+		nvs[i] = p.Name() + " = " + vals[i]
+	}
+	set := &params.Set{Name: name, Desc: "sweep values: " + strings.Join(nvs, ", "), Sheets: make(params.Sheets)}
+	for i, p := range ps {
+		sht, ok := set.Sheets[p.Sheet]
+		if !ok {
+			sht = &params.Sheet{}
+			set.Sheets[p.Sheet] = sht
+		}
+		*sht = append(*sht, &params.Sel{Sel: p.Sel, Desc: "sweep", Params: params.Params{p.Path: vals[i]}})
+	}
+	return set
+}
+
+// RunScore is the result of one training run, from TrainScored
+type RunScore struct {
+	FirstZero int     `desc:"epoch at which training error first went to zero, -1 if never"`
+	Epochs    int     `desc:"number of epochs trained"`
+	PctCor    float64 `desc:"training PctCor over the last epochs, as in the RunLog"`
+	CosDiff   float64 `desc:"training CosDiff over the last epochs, as in the RunLog"`
+	TstPctCor float64 `desc:"testing PctCor after training"`
+	StopRule  string  `desc:"stopping rule that ended the run"`
+}
+
+// TrainScored merges given params.Set into Params and applies it on top of
+// the ParamSet, trains one run from given random seed, runs TestAll on given
+// split of objects, and returns the results.  The run is also recorded in
+// the RunLog, under the ParamSet plus the set name.
+func (ss *Sim) TrainScored(set *params.Set, seed int64, split string) RunScore {
+	return ss.TrainScoredFrom(set, seed, split, nil)
+}
+
+// ScoredState is the state at the end of a TrainScoredFrom run that is
+// needed to resume training it for more epochs, as in successive halving
+type ScoredState struct {
+	Wts       string `desc:"file the weights are saved to"`
+	Epoch     int    `desc:"number of epochs trained so far -- 0 to start from scratch"`
+	FirstZero int    `desc:"epoch at which training error first went to zero, -1 if never"`
+	NZero     int    `desc:"number of epochs in a row with zero training errors"`
+	Curric    Curric `desc:"curriculum state"`
+	TrainIdx  int    `desc:"index of the next stimulus in the TrainEnv Prefetch sequence"`
+	NovelIdx  int    `desc:"index of the next stimulus in the NovelTrainEnv Prefetch sequence"`
+}
+
+// TrainScoredFrom is TrainScored, resuming training from given state if it
+// has any epochs trained, up to MaxEpcs in total.  If st is non-nil, the
+// state at the end of training is saved into it, with the weights saved to
+// its Wts file.
+func (ss *Sim) TrainScoredFrom(set *params.Set, seed int64, split string, st *ScoredState) RunScore {
+	MergeParamSets(&ss.Params, params.Sets{set})
+	pset := ss.ParamSet
+	ss.ParamSet = strings.TrimSpace(pset + " " + set.Name)
+	ss.RndSeed = seed
+	ss.MaxRuns = 1
+	ss.TrainEnv.Run.Max = 1
+	ss.NovelTrainEnv.Run.Max = 1
+	ss.TrainEnv.Run.Cur = 0
+	ss.Init()
+	if st != nil && st.Epoch > 0 {
+		err := ss.ResumeScored(st)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+	ss.Train()
+	ss.ParamSet = pset
+	if st != nil && !ss.Interrupted {
+		st.Epoch = ss.TrainEnv.Epoch.Cur
+		st.FirstZero = ss.FirstZero
+		st.NZero = ss.NZero
+		st.Curric = ss.Curric
+		st.TrainIdx = ss.TrainEnv.Prefetch.Idx
+		st.NovelIdx = ss.NovelTrainEnv.Prefetch.Idx
+		err := ss.Net.SaveWtsJSON(gi.FileName(st.Wts))
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+
+	rl := ss.RunLog
+	row := rl.Rows - 1
+	sc := RunScore{FirstZero: ss.FirstZero, StopRule: ss.StopRule}
+	if row >= 0 {
+		sc.Epochs = int(rl.CellFloat("Epochs", row))
+		sc.PctCor = rl.CellFloat("PctCor", row)
+		sc.CosDiff = rl.CellFloat("CosDiff", row)
+	}
+	if ss.Interrupted {
+		return sc
+	}
+	err := ss.SetTestSplit(split)
+	if err != nil {
+		fmt.Println(err)
+	}
+	ss.TestAll()
+	sc.TstPctCor = 1 - ss.TstPctErr()
+	if row >= 0 {
+		rl.SetCellFloat("TstPctCor", row, sc.TstPctCor)
+	}
+	return sc
+}
+
+// ResumeScored restores given state saved by TrainScoredFrom, after Init:
+// the weights, epoch, learning rate schedule, zero-error stats, curriculum,
+// and position in the Prefetch stimulus sequences, which Init restarts.
+func (ss *Sim) ResumeScored(st *ScoredState) error {
+	err := ss.Net.OpenWtsJSON(gi.FileName(st.Wts))
+	if err != nil {
+		return fmt.Errorf("resume: %s: %v", st.Wts, err)
+	}
+	ss.TrainEnv.Epoch.Cur = st.Epoch
+	for epc := 1; epc <= st.Epoch; epc++ {
+		ss.LrateSched(epc)
+	}
+	ss.FirstZero = st.FirstZero
+	ss.NZero = st.NZero
+	ss.TrainEnv.Prefetch.Idx = st.TrainIdx
+	ss.NovelTrainEnv.Prefetch.Idx = st.NovelIdx
+	if ss.Curric.On {
+		ss.Curric = st.Curric
+		ss.Curric.Apply(&ss.TrainEnv.XFormRand)
+	}
+	return nil
+}
+
+// ScoreSchema returns the table columns for a RunScore
+func ScoreSchema() etable.Schema {
+	return etable.Schema{
+		{"FirstZero", etensor.INT64, nil, nil},
+		{"Epochs", etensor.INT64, nil, nil},
+		{"PctCor", etensor.FLOAT64, nil, nil},
+		{"CosDiff", etensor.FLOAT64, nil, nil},
+		{"TstPctCor", etensor.FLOAT64, nil, nil},
+		{"StopRule", etensor.STRING, nil, nil},
+	}
+}
+
+// SetScore sets the RunScore columns of given row of table
+func SetScore(dt *etable.Table, row int, sc *RunScore) {
+	dt.SetCellFloat("FirstZero", row, float64(sc.FirstZero))
+	dt.SetCellFloat("Epochs", row, float64(sc.Epochs))
+	dt.SetCellFloat("PctCor", row, sc.PctCor)
+	dt.SetCellFloat("CosDiff", row, sc.CosDiff)
+	dt.SetCellFloat("TstPctCor", row, sc.TstPctCor)
+	dt.SetCellString("StopRule", row, sc.StopRule)
+}
+
+// CmdSweep runs the parameter sweep in given spec file: each combination of
+// values is trained for Seeds runs, and tested on given split of objects,
+// with the results of every run saved to the sweep log, keyed by the values,
+// and their descriptive stats over seeds for each combination saved to the
+// sweep summary log.  Each combination is recorded in the RunLog under the
+// ParamSet plus Sweep and its Cell index, e.g., Sweep3.
+func (ss *Sim) CmdSweep(fnm, split string) {
+	sp, err := OpenSweepSpec(fnm)
+	if err != nil {
+		fmt.Printf("sweep: %v\n", err)
+		return
+	}
+	nseed := sp.Seeds
+	if nseed <= 0 {
+		nseed = ss.MaxRuns
+	}
+	cells := sp.Cells()
+	fmt.Printf("Sweeping %d combinations x %d seeds\n", len(cells), nseed)
+	dt := &etable.Table{}
+	ss.ConfigSweepLog(dt, sp)
+	lfnm := ss.LogFileName("sweep")
+	sfnm := ss.LogFileName("sweepsum")
+	seed0 := ss.RndSeed
+	for ci, vals := range cells {
+		set := SweepParamSet(fmt.Sprintf("Sweep%d", ci), sp.Params, vals)
+		for si := 0; si < nseed; si++ {
+			sc := ss.TrainScored(set, seed0+int64(si), split)
+			if ss.Interrupted {
+				fmt.Printf("sweep: interrupted -- results so far are in: %s\n", lfnm)
+				return
+			}
+			row := dt.Rows
+			dt.SetNumRows(row + 1)
+			dt.SetCellFloat("Cell", row, float64(ci))
+			dt.SetCellFloat("Seed", row, float64(seed0+int64(si)))
+			for pi, p := range sp.Params {
+				dt.SetCellString(p.Name(), row, vals[pi])
+			}
+			SetScore(dt, row, &sc)
+			fmt.Printf("Cell: %d\t%s\tSeed: %d\tFirstZero: %d\tTstPctCor: %.4f\n", ci, strings.Join(vals, " "), seed0+int64(si), sc.FirstZero, sc.TstPctCor)
+			dt.SaveCSV(gi.FileName(lfnm), etable.Tab, etable.Headers) // save as we go
+		}
+		err = SweepSummary(dt, sp).SaveCSV(gi.FileName(sfnm), etable.Tab, etable.Headers)
+		if err != nil {
+			fmt.Printf("sweep: %v\n", err)
+		}
+	}
+	fmt.Printf("Saved sweep results to: %s and summary to: %s\n", lfnm, sfnm)
+}
+
+// SweepSummary returns the descriptive stats of FirstZero, PctCor and
+// TstPctCor over the runs of each combination of param values in given
+// sweep log -- by Cell if there are no params
+func SweepSummary(dt *etable.Table, sp *SweepSpec) *etable.Table {
+	cols := []string{"Cell"}
+	if len(sp.Params) > 0 {
+		cols = make([]string, len(sp.Params))
+		for i, p := range sp.Params {
+			cols[i] = p.Name()
+		}
+	}
+	spl := split.GroupBy(etable.NewIdxView(dt), cols)
+	split.Desc(spl, "FirstZero")
+	split.Desc(spl, "PctCor")
+	split.Desc(spl, "TstPctCor")
+	sum := spl.AggsToTable(etable.AddAggName)
+	sum.SetMetaData("name", "SweepSummary")
+	sum.SetMetaData("desc", "Stats over seeds of each combination of values of a parameter sweep")
+	sum.SetMetaData("precision", strconv.Itoa(LogPrec))
+	return sum
+}
+
+func (ss *Sim) ConfigSweepLog(dt *etable.Table, sp *SweepSpec) {
+	dt.SetMetaData("name", "SweepLog")
+	dt.SetMetaData("desc", "Results of each run of a parameter sweep")
+	dt.SetMetaData("read-only", "true")
+	dt.SetMetaData("precision", strconv.Itoa(LogPrec))
+
+	sch := etable.Schema{
+		{"Cell", etensor.INT64, nil, nil},
+		{"Seed", etensor.INT64, nil, nil},
+	}
+	for _, p := range sp.Params {
+		sch = append(sch, etable.Column{p.Name(), etensor.STRING, nil, nil})
+	}
+	sch = append(sch, ScoreSchema()...)
+	dt.SetFromSchema(sch, 0)
+}
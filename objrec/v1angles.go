@@ -0,0 +1,132 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+
+	"github.com/emer/etable/etensor"
+	"github.com/emer/vision/kwta"
+	"github.com/goki/mat32"
+)
+
+// The v1complex LenSum4, EndStop4 and kwta NeighInhib.Inhib4 functions only
+// work with 4 angles -- these versions work with any number of angles in the
+// inner-most dimension, for gabor filters with NAngles other than 4.
+
+// LineDir returns the grid offset (x, y) along the direction of given angle
+// out of nang angles, where angle 0 is horizontal and angles increase
+// counter-clockwise over 180 degrees, as in the gabor filters.  If orth, the
+// direction is orthogonal to the angle.  The offset is the shortest one whose
+// direction is within half the spacing between angles, so each angle gets its
+// own distinct offset -- e.g., (2, 1) for 22.5 or 30 degrees, where the
+// nearest neighbor (1, 0) or (1, 1) would be the same as for another angle.
+func LineDir(ang, nang int, orth bool) (x, y int) {
+	a := math.Pi * float64(ang) / float64(nang)
+	if orth {
+		a += math.Pi / 2
+	}
+	tol := 0.5 * math.Pi / float64(nang)
+	for r := 1; ; r++ {
+		minErr := math.Inf(1)
+		for oy := -r; oy <= r; oy++ {
+			for ox := -r; ox <= r; ox++ {
+				if ox == 0 && oy == 0 {
+					continue
+				}
+				d := math.Abs(math.Remainder(math.Atan2(float64(oy), float64(ox))-a, 2*math.Pi))
+				if d < minErr-1e-9 || (d < minErr+1e-9 && ox*ox+oy*oy < x*x+y*y) {
+					minErr = d
+					x, y = ox, oy
+				}
+			}
+		}
+		if minErr < tol-1e-9 {
+			return
+		}
+	}
+}
+
+// NeighInhibN computes the neighborhood inhibition of ni on act into extGi:
+// each unit gets inhibition from the same feature at the LineDir offsets on
+// either side, orthogonal to its angle.
+func NeighInhibN(ni *kwta.NeighInhib, act, extGi *etensor.Float32) {
+	extGi.CopyShapeFrom(act)
+	ny, nx, npol, nang := act.Dim(0), act.Dim(1), act.Dim(2), act.Dim(3)
+	for ang := 0; ang < nang; ang++ {
+		ox, oy := LineDir(ang, nang, true)
+		for y := 0; y < ny; y++ {
+			for x := 0; x < nx; x++ {
+				for pol := 0; pol < npol; pol++ {
+					gi := float32(0)
+					for _, s := range []int{1, -1} {
+						px, py := x+s*ox, y+s*oy
+						if px >= 0 && px < nx && py >= 0 && py < ny {
+							gi = mat32.Max(gi, ni.Gi*act.Value([]int{py, px, pol, ang}))
+						}
+					}
+					extGi.Set([]int{y, x, pol, ang}, gi)
+				}
+			}
+		}
+	}
+}
+
+// LenSum computes length-sum complex cell features from angle-only input
+// act: the average of each angle at the unit and at the LineDir offsets on
+// either side along that angle.
+func LenSum(act, lsum *etensor.Float32) {
+	ny, nx, nang := act.Dim(0), act.Dim(1), act.Dim(3)
+	lsum.SetShape([]int{ny, nx, 1, nang}, nil, []string{"Y", "X", "Polarity", "Angle"})
+	norm := float32(1) / 3
+	for ang := 0; ang < nang; ang++ {
+		lx, ly := LineDir(ang, nang, false)
+		for y := 0; y < ny; y++ {
+			for x := 0; x < nx; x++ {
+				sum := act.Value([]int{y, x, 0, ang})
+				for _, s := range []int{1, -1} {
+					px, py := x+s*lx, y+s*ly
+					if px >= 0 && px < nx && py >= 0 && py < ny {
+						sum += act.Value([]int{py, px, 0, ang})
+					}
+				}
+				lsum.Set([]int{y, x, 0, ang}, norm*sum)
+			}
+		}
+	}
+}
+
+// EndStop computes end-stop complex cell features from angle-only input act
+// and its length sums lsum: for each of the two directions along each angle
+// (Polarity rows 0, 1), the length sum one step in that direction, minus the
+// max activity over all angles one step in the opposite direction -- i.e.,
+// a line that ends at the unit.
+func EndStop(act, lsum, estop *etensor.Float32) {
+	ny, nx, nang := act.Dim(0), act.Dim(1), act.Dim(3)
+	estop.SetShape([]int{ny, nx, 2, nang}, nil, []string{"Y", "X", "Polarity", "Angle"})
+	for ang := 0; ang < nang; ang++ {
+		lx, ly := LineDir(ang, nang, false)
+		for y := 0; y < ny; y++ {
+			for x := 0; x < nx; x++ {
+				for dir, s := range []int{1, -1} {
+					es := float32(0)
+					onx, ony := x+s*lx, y+s*ly
+					if onx >= 0 && onx < nx && ony >= 0 && ony < ny {
+						es = lsum.Value([]int{ony, onx, 0, ang})
+						offx, offy := x-s*lx, y-s*ly
+						if offx >= 0 && offx < nx && offy >= 0 && offy < ny {
+							off := float32(0)
+							for oa := 0; oa < nang; oa++ {
+								off = mat32.Max(off, act.Value([]int{offy, offx, 0, oa}))
+							}
+							es = mat32.Max(es-off, 0)
+						}
+					}
+					estop.Set([]int{y, x, dir, ang}, es)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLineDir(t *testing.T) {
+	type off struct{ x, y int }
+	tests := []struct {
+		nang int
+		orth bool
+		offs []off
+	}{
+		{6, false, []off{{1, 0}, {2, 1}, {1, 2}, {0, 1}, {-1, 2}, {-2, 1}}},
+		{6, true, []off{{0, 1}, {-1, 2}, {-2, 1}, {-1, 0}, {-2, -1}, {-1, -2}}},
+		{8, false, []off{{1, 0}, {2, 1}, {1, 1}, {1, 2}, {0, 1}, {-1, 2}, {-1, 1}, {-2, 1}}},
+		{8, true, []off{{0, 1}, {-1, 2}, {-1, 1}, {-2, 1}, {-1, 0}, {-2, -1}, {-1, -1}, {-1, -2}}},
+	}
+	for _, ts := range tests {
+		lines := make(map[off]int) // offsets as lines, with either sign
+		for ang := 0; ang < ts.nang; ang++ {
+			x, y := LineDir(ang, ts.nang, ts.orth)
+			if (off{x, y}) != ts.offs[ang] {
+				t.Errorf("LineDir(%d, %d, %v) = (%d, %d), want %v", ang, ts.nang, ts.orth, x, y, ts.offs[ang])
+			}
+			a := math.Pi * float64(ang) / float64(ts.nang)
+			if ts.orth {
+				a += math.Pi / 2
+			}
+			d := math.Abs(math.Remainder(math.Atan2(float64(y), float64(x))-a, 2*math.Pi))
+			if d >= 0.5*math.Pi/float64(ts.nang) {
+				t.Errorf("LineDir(%d, %d, %v) = (%d, %d) is %g degrees off the angle", ang, ts.nang, ts.orth, x, y, d*180/math.Pi)
+			}
+			ln := off{x, y}
+			if x < 0 || (x == 0 && y < 0) {
+				ln = off{-x, -y}
+			}
+			if pa, has := lines[ln]; has {
+				t.Errorf("LineDir(%d, %d, %v) = (%d, %d) is the same line as for angle %d", ang, ts.nang, ts.orth, x, y, pa)
+			}
+			lines[ln] = ang
+		}
+	}
+}
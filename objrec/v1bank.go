@@ -0,0 +1,79 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+
+	"github.com/emer/etable/etensor"
+	"github.com/emer/vision/vxform"
+)
+
+// V1Bank is a bank of V1 filter outputs for each object under random
+// transforms, filled as the objects are drawn: until an object has N
+// samples, each draw renders and filters a new random transform of it as
+// usual, and adds the result to the bank -- after that, each draw picks one
+// of its samples at random, skipping the rendering and filtering (the env
+// Draw.Image is then not updated).
+// This trades stimulus variety (N transforms per object) for speed.
+// The bank is cleared when the XFormRand ranges change (e.g., with Curric).
+type V1Bank struct {
+	N         int                 `desc:"number of samples per object -- 0 = off"`
+	XFormRand vxform.Rand         `view:"-" desc:"transform ranges the samples were made with"`
+	Samples   map[int][]*V1Sample `view:"-" desc:"samples for each object"`
+}
+
+// V1Sample is one sample in a V1Bank
+type V1Sample struct {
+	XForm vxform.XForm       `desc:"transform of the sample"`
+	Img   *etensor.Float32   `desc:"ImgTsr of the standard scale"`
+	V1    []*etensor.Float32 `desc:"V1AllTsr of each scale"`
+}
+
+// Reset clears all the samples
+func (vb *V1Bank) Reset() {
+	vb.Samples = make(map[int][]*V1Sample)
+}
+
+// Update resets the bank if the XFormRand ranges of given env have changed
+func (vb *V1Bank) Update(ev *LEDEnv) {
+	if vb.Samples == nil || vb.XFormRand != ev.XFormRand {
+		vb.Reset()
+		vb.XFormRand = ev.XFormRand
+	}
+}
+
+// Full returns true if the bank has all N samples for given object, so it
+// does not need to be drawn for Filter
+func (vb *V1Bank) Full(ev *LEDEnv, led int) bool {
+	vb.Update(ev)
+	return len(vb.Samples[led]) >= vb.N
+}
+
+// Filter sets the Vis outputs of given env for its CurLED object, from a
+// sample in the bank, or by filtering a new random transform of the drawn
+// object that is added to the bank, if it does not yet have N samples for
+// the object -- the object must have been drawn unless it is Full.
+func (vb *V1Bank) Filter(ev *LEDEnv) {
+	vb.Update(ev)
+	vi := &ev.Vis
+	sms := vb.Samples[ev.CurLED]
+	if len(sms) < vb.N {
+		ev.XFormRand.Gen(&ev.XForm)
+		vi.Filter(ev.XForm.Image(ev.Draw.Image))
+		sm := &V1Sample{XForm: ev.XForm, Img: vi.ImgTsr.Clone().(*etensor.Float32)}
+		for sc := 0; sc < vi.NScales; sc++ {
+			sm.V1 = append(sm.V1, vi.Scale(sc).V1AllTsr.Clone().(*etensor.Float32))
+		}
+		vb.Samples[ev.CurLED] = append(sms, sm)
+		return
+	}
+	sm := sms[rand.Intn(len(sms))]
+	ev.XForm = sm.XForm
+	vi.ImgTsr.CopyFrom(sm.Img)
+	for sc, v1 := range sm.V1 {
+		vi.Scale(sc).V1AllTsr.CopyFrom(v1)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// BenchmarkTrainTrial measures the time per training trial (ns/op, vs.
+// msec in PerTrlMSec), without and with the V1Bank -- once the bank is
+// full (after N trials per object), trials skip drawing and filtering.
+func BenchmarkTrainTrial(b *testing.B) {
+	for _, n := range []int{0, 2} {
+		b.Run(fmt.Sprintf("bank=%d", n), func(b *testing.B) {
+			ss := &Sim{}
+			ss.New()
+			ss.MaxRuns = 1
+			ss.MaxEpcs = 1000000
+			ss.Config()
+			ss.TrainEnv.Bank.N = n
+			ss.Init()
+			nobj := 1 + ss.TrainEnv.MaxLED - ss.TrainEnv.MinLED
+			for i := 0; i < 10*n*nobj; i++ { // fill the bank
+				ss.TrainTrial()
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ss.TrainTrial()
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"fmt"
 	"image"
 
 	"github.com/anthonynsimon/bild/transform"
@@ -17,16 +18,16 @@ import (
 	"github.com/goki/ki/kit"
 )
 
-// Vis encapsulates specific visual processing pipeline for V1 filtering
-type Vis struct {
+// V1Scale is the V1 filtering pipeline at one gabor filter scale: simple
+// cell gabor filtering with neighborhood inhibition and kwta, then
+// length-sum and end-stop complex cells, all combined into V1AllTsr.
+type V1Scale struct {
 	V1sGabor      gabor.Filter    `desc:"V1 simple gabor filter parameters"`
 	V1sGeom       vfilter.Geom    `inactive:"+" view:"inline" desc:"geometry of input, output for V1 simple-cell processing"`
 	V1sNeighInhib kwta.NeighInhib `desc:"neighborhood inhibition for V1s -- each unit gets inhibition from same feature in nearest orthogonal neighbors -- reduces redundancy of feature code"`
 	V1sKWTA       kwta.KWTA       `desc:"kwta parameters for V1s"`
-	ImgSize       image.Point     `desc:"target image size to use -- images will be rescaled to this size"`
 	V1sGaborTsr   etensor.Float32 `view:"no-inline" desc:"V1 simple gabor filter tensor"`
-	ImgTsr        etensor.Float32 `view:"no-inline" desc:"input image as tensor"`
-	Img           image.Image     `view:"-" desc:"current input image"`
+	ImgTsr        etensor.Float32 `view:"no-inline" desc:"input image as tensor, padded for this filter size"`
 	V1sTsr        etensor.Float32 `view:"no-inline" desc:"V1 simple gabor filter output tensor"`
 	V1sExtGiTsr   etensor.Float32 `view:"no-inline" desc:"V1 simple extra Gi from neighbor inhibition tensor"`
 	V1sKwtaTsr    etensor.Float32 `view:"no-inline" desc:"V1 simple gabor filter output, kwta output tensor"`
@@ -40,85 +41,220 @@ type Vis struct {
 	V1sInhibs     fffb.Inhibs     `view:"no-inline" desc:"inhibition values for V1s KWTA"`
 }
 
-var KiT_Vis = kit.Types.AddType(&Vis{}, nil)
+var KiT_V1Scale = kit.Types.AddType(&V1Scale{}, nil)
 
-func (vi *Vis) Defaults() {
-	vi.V1sGabor.Defaults()
-	sz := 6 // V1mF16 typically = 12, no border, spc = 4 -- using 1/2 that here
-	spc := 2
-	vi.V1sGabor.SetSize(sz, spc)
+// Defaults sets the default params for gabor filters of given size, spacing
+// and number of angles
+func (vs *V1Scale) Defaults(sz, spc, nang int) {
+	vs.V1sGabor.Defaults()
+	vs.V1sGabor.SetSize(sz, spc)
+	vs.V1sGabor.NAngles = nang
 	// note: first arg is border -- we are relying on Geom
 	// to set border to .5 * filter size
 	// any further border sizes on same image need to add Geom.FiltRt!
-	vi.V1sGeom.Set(image.Point{0, 0}, image.Point{spc, spc}, image.Point{sz, sz})
-	vi.V1sNeighInhib.Defaults()
-	vi.V1sKWTA.Defaults()
-	vi.ImgSize = image.Point{40, 40}
-	vi.V1sGabor.ToTensor(&vi.V1sGaborTsr)
-	// vi.ImgTsr.SetMetaData("image", "+")
-	vi.ImgTsr.SetMetaData("grid-fill", "1")
+	vs.V1sGeom.Set(image.Point{0, 0}, image.Point{spc, spc}, image.Point{sz, sz})
+	vs.V1sNeighInhib.Defaults()
+	vs.V1sKWTA.Defaults()
+	vs.V1sGabor.ToTensor(&vs.V1sGaborTsr)
+	// vs.ImgTsr.SetMetaData("image", "+")
+	vs.ImgTsr.SetMetaData("grid-fill", "1")
 }
 
-// SetImage sets current image for processing
-func (vi *Vis) SetImage(img image.Image) {
-	vi.Img = img
-	isz := vi.Img.Bounds().Size()
-	if isz != vi.ImgSize {
-		vi.Img = transform.Resize(vi.Img, vi.ImgSize.X, vi.ImgSize.Y, transform.Linear)
-	}
-	vfilter.RGBToGrey(vi.Img, &vi.ImgTsr, vi.V1sGeom.FiltRt.X, false) // pad for filt, bot zero
-	vfilter.WrapPad(&vi.ImgTsr, vi.V1sGeom.FiltRt.X)
+// CopyParams copies the params of given V1Scale, and makes its own
+// gabor filter tensor, so it can filter independently of it
+func (vs *V1Scale) CopyParams(fm *V1Scale) {
+	vs.V1sGabor = fm.V1sGabor
+	vs.V1sGeom = fm.V1sGeom
+	vs.V1sNeighInhib = fm.V1sNeighInhib
+	vs.V1sKWTA = fm.V1sKWTA
+	vs.V1sGabor.ToTensor(&vs.V1sGaborTsr)
+	vs.ImgTsr.SetMetaData("grid-fill", "1")
+}
+
+// SetAngles sets the number of gabor filter angles
+func (vs *V1Scale) SetAngles(nang int) {
+	vs.V1sGabor.NAngles = nang
+	vs.V1sGabor.ToTensor(&vs.V1sGaborTsr)
+}
+
+// SetImage sets the ImgTsr from given image, which must already be
+// at the target size
+func (vs *V1Scale) SetImage(img image.Image) {
+	vfilter.RGBToGrey(img, &vs.ImgTsr, vs.V1sGeom.FiltRt.X, false) // pad for filt, bot zero
+	vfilter.WrapPad(&vs.ImgTsr, vs.V1sGeom.FiltRt.X)
 }
 
 // V1Simple runs V1Simple Gabor filtering on input image
-// must have valid Img in place to start.
+// must have valid ImgTsr in place to start.
 // Runs kwta and pool steps after gabor filter.
-func (vi *Vis) V1Simple() {
-	vfilter.Conv(&vi.V1sGeom, &vi.V1sGaborTsr, &vi.ImgTsr, &vi.V1sTsr, vi.V1sGabor.Gain)
-	if vi.V1sNeighInhib.On {
-		vi.V1sNeighInhib.Inhib4(&vi.V1sTsr, &vi.V1sExtGiTsr)
-	} else {
-		vi.V1sExtGiTsr.SetZeros()
+func (vs *V1Scale) V1Simple() {
+	vfilter.Conv(&vs.V1sGeom, &vs.V1sGaborTsr, &vs.ImgTsr, &vs.V1sTsr, vs.V1sGabor.Gain)
+	switch {
+	case vs.V1sNeighInhib.On && vs.V1sGabor.NAngles == 4:
+		vs.V1sNeighInhib.Inhib4(&vs.V1sTsr, &vs.V1sExtGiTsr)
+	case vs.V1sNeighInhib.On:
+		NeighInhibN(&vs.V1sNeighInhib, &vs.V1sTsr, &vs.V1sExtGiTsr)
+	default:
+		vs.V1sExtGiTsr.SetZeros()
 	}
-	if vi.V1sKWTA.On {
-		vi.V1sKWTA.KWTAPool(&vi.V1sTsr, &vi.V1sKwtaTsr, &vi.V1sInhibs, &vi.V1sExtGiTsr)
+	if vs.V1sKWTA.On {
+		vs.V1sKWTA.KWTAPool(&vs.V1sTsr, &vs.V1sKwtaTsr, &vs.V1sInhibs, &vs.V1sExtGiTsr)
 	} else {
-		vi.V1sKwtaTsr.CopyFrom(&vi.V1sTsr)
+		vs.V1sKwtaTsr.CopyFrom(&vs.V1sTsr)
 	}
 }
 
 // it computes Angle-only, max-pooled version of V1Simple inputs.
-func (vi *Vis) V1Complex() {
-	vfilter.MaxPool(image.Point{2, 2}, image.Point{2, 2}, &vi.V1sKwtaTsr, &vi.V1sPoolTsr)
-	vfilter.MaxReduceFilterY(&vi.V1sKwtaTsr, &vi.V1sAngOnlyTsr)
-	vfilter.MaxPool(image.Point{2, 2}, image.Point{2, 2}, &vi.V1sAngOnlyTsr, &vi.V1sAngPoolTsr)
-	v1complex.LenSum4(&vi.V1sAngPoolTsr, &vi.V1cLenSumTsr)
-	v1complex.EndStop4(&vi.V1sAngPoolTsr, &vi.V1cLenSumTsr, &vi.V1cEndStopTsr)
+func (vs *V1Scale) V1Complex() {
+	vfilter.MaxPool(image.Point{2, 2}, image.Point{2, 2}, &vs.V1sKwtaTsr, &vs.V1sPoolTsr)
+	vfilter.MaxReduceFilterY(&vs.V1sKwtaTsr, &vs.V1sAngOnlyTsr)
+	vfilter.MaxPool(image.Point{2, 2}, image.Point{2, 2}, &vs.V1sAngOnlyTsr, &vs.V1sAngPoolTsr)
+	if vs.V1sGabor.NAngles == 4 {
+		v1complex.LenSum4(&vs.V1sAngPoolTsr, &vs.V1cLenSumTsr)
+		v1complex.EndStop4(&vs.V1sAngPoolTsr, &vs.V1cLenSumTsr, &vs.V1cEndStopTsr)
+	} else {
+		LenSum(&vs.V1sAngPoolTsr, &vs.V1cLenSumTsr)
+		EndStop(&vs.V1sAngPoolTsr, &vs.V1cLenSumTsr, &vs.V1cEndStopTsr)
+	}
 }
 
 // V1All aggregates all the relevant simple and complex features
 // into the V1AllTsr which is used for input to a network
-func (vi *Vis) V1All() {
-	ny := vi.V1sPoolTsr.Dim(0)
-	nx := vi.V1sPoolTsr.Dim(1)
-	nang := vi.V1sPoolTsr.Dim(3)
+func (vs *V1Scale) V1All() {
+	ny := vs.V1sPoolTsr.Dim(0)
+	nx := vs.V1sPoolTsr.Dim(1)
+	nang := vs.V1sPoolTsr.Dim(3)
 	nrows := 5
 	oshp := []int{ny, nx, nrows, nang}
-	if !etensor.EqualInts(oshp, vi.V1AllTsr.Shp) {
-		vi.V1AllTsr.SetShape(oshp, nil, []string{"Y", "X", "Polarity", "Angle"})
+	if !etensor.EqualInts(oshp, vs.V1AllTsr.Shp) {
+		vs.V1AllTsr.SetShape(oshp, nil, []string{"Y", "X", "Polarity", "Angle"})
 	}
 	// 1 length-sum
-	vfilter.FeatAgg([]int{0}, 0, &vi.V1cLenSumTsr, &vi.V1AllTsr)
+	vfilter.FeatAgg([]int{0}, 0, &vs.V1cLenSumTsr, &vs.V1AllTsr)
 	// 2 end-stop
-	vfilter.FeatAgg([]int{0, 1}, 1, &vi.V1cEndStopTsr, &vi.V1AllTsr)
+	vfilter.FeatAgg([]int{0, 1}, 1, &vs.V1cEndStopTsr, &vs.V1AllTsr)
 	// 2 pooled simple cell
-	vfilter.FeatAgg([]int{0, 1}, 3, &vi.V1sPoolTsr, &vi.V1AllTsr)
+	vfilter.FeatAgg([]int{0, 1}, 3, &vs.V1sPoolTsr, &vs.V1AllTsr)
+}
+
+// Filter runs all the filters on the current ImgTsr
+func (vs *V1Scale) Filter() {
+	vs.V1Simple()
+	vs.V1Complex()
+	vs.V1All()
+}
+
+// Vis encapsulates specific visual processing pipeline for V1 filtering
+type Vis struct {
+	V1Scale     `desc:"standard (finest) scale of V1 filtering, which is the input to the V1 layer"`
+	NScales     int         `min:"1" desc:"number of V1 filtering scales -- 1 = standard scale only -- each additional scale has ScaleFactor times the filter size and spacing of the previous one, and is the input to its own V1ScaleN layer"`
+	ScaleFactor int         `min:"2" desc:"factor by which the filter size and spacing increase from one scale to the next"`
+	Coarse      []V1Scale   `view:"no-inline" desc:"additional coarser scales beyond the standard one, if NScales > 1"`
+	ImgSize     image.Point `desc:"target image size to use -- images will be rescaled to this size"`
+	Img         image.Image `view:"-" desc:"current input image"`
+}
+
+var KiT_Vis = kit.Types.AddType(&Vis{}, nil)
+
+func (vi *Vis) Defaults() {
+	sz := 6 // V1mF16 typically = 12, no border, spc = 4 -- using 1/2 that here
+	spc := 2
+	vi.V1Scale.Defaults(sz, spc, 4)
+	vi.ImgSize = image.Point{40, 40}
+	vi.ScaleFactor = 2
+	vi.SetScales(1)
+}
+
+// SetScales sets the number of scales, configuring the coarser scales
+// beyond the standard one, with the same number of angles
+func (vi *Vis) SetScales(n int) {
+	if n < 1 {
+		n = 1
+	}
+	vi.NScales = n
+	vi.Coarse = make([]V1Scale, n-1)
+	sz := vi.V1sGabor.Size
+	spc := vi.V1sGabor.Spacing
+	for i := range vi.Coarse {
+		sz *= vi.ScaleFactor
+		spc *= vi.ScaleFactor
+		vi.Coarse[i].Defaults(sz, spc, vi.V1sGabor.NAngles)
+	}
+}
+
+// SetAngles sets the number of gabor filter angles, for all scales
+func (vi *Vis) SetAngles(nang int) {
+	for sc := 0; sc < vi.NScales; sc++ {
+		vi.Scale(sc).SetAngles(nang)
+	}
+}
+
+// CloneParams returns a new Vis with the same params as this one, and its
+// own tensors, so it can filter in parallel with it
+func (vi *Vis) CloneParams() *Vis {
+	nv := &Vis{NScales: vi.NScales, ScaleFactor: vi.ScaleFactor, ImgSize: vi.ImgSize}
+	nv.V1Scale.CopyParams(&vi.V1Scale)
+	nv.Coarse = make([]V1Scale, len(vi.Coarse))
+	for i := range vi.Coarse {
+		nv.Coarse[i].CopyParams(&vi.Coarse[i])
+	}
+	return nv
+}
+
+// Scale returns the V1Scale for given scale index, 0 = standard
+func (vi *Vis) Scale(sc int) *V1Scale {
+	if sc == 0 {
+		return &vi.V1Scale
+	}
+	return &vi.Coarse[sc-1]
+}
+
+// V1ScaleLayName returns the name of the layer for given scale index:
+// V1 for the standard scale, V1ScaleN for the coarser ones
+func V1ScaleLayName(sc int) string {
+	if sc == 0 {
+		return "V1"
+	}
+	return fmt.Sprintf("V1Scale%d", sc)
 }
 
-// Filter is overall method to run filters on given image
+// ScaleByName returns the V1Scale for given layer name, nil if none
+func (vi *Vis) ScaleByName(lnm string) *V1Scale {
+	for sc := 0; sc < vi.NScales; sc++ {
+		if V1ScaleLayName(sc) == lnm {
+			return vi.Scale(sc)
+		}
+	}
+	return nil
+}
+
+// Shapes returns the shape of the V1AllTsr of each scale, by filtering a
+// blank image -- for configuring the V1 layers
+func (vi *Vis) Shapes() [][]int {
+	vi.Filter(image.NewGray(image.Rect(0, 0, vi.ImgSize.X, vi.ImgSize.Y)))
+	shps := make([][]int, vi.NScales)
+	for sc := range shps {
+		shps[sc] = append([]int{}, vi.Scale(sc).V1AllTsr.Shapes()...)
+	}
+	return shps
+}
+
+// SetImage sets current image for processing, for all scales
+func (vi *Vis) SetImage(img image.Image) {
+	vi.Img = img
+	isz := vi.Img.Bounds().Size()
+	if isz != vi.ImgSize {
+		vi.Img = transform.Resize(vi.Img, vi.ImgSize.X, vi.ImgSize.Y, transform.Linear)
+	}
+	for sc := 0; sc < vi.NScales; sc++ {
+		vi.Scale(sc).SetImage(vi.Img)
+	}
+}
+
+// Filter is overall method to run filters on given image, at all scales
 func (vi *Vis) Filter(img image.Image) {
 	vi.SetImage(img)
-	vi.V1Simple()
-	vi.V1Complex()
-	vi.V1All()
+	for sc := 0; sc < vi.NScales; sc++ {
+		vi.Scale(sc).Filter()
+	}
 }
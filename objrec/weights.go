@@ -0,0 +1,88 @@
+// Copyright (c) 2019, The Emergent Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"compress/gzip"
+	"embed"
+	"io/fs"
+	"strings"
+
+	"github.com/goki/gi/gi"
+	"github.com/goki/gi/giv"
+	"github.com/goki/ki/ki"
+)
+
+// EmbedWts holds the trained weights files shipped with the sim
+//
+//go:embed objrec_train1.wts.gz objrec_train2.wts.gz
+var EmbedWts embed.FS
+
+// EmbedWtsNames returns the names of the embedded trained weights,
+// which are the file names without the .wts.gz extension.
+func EmbedWtsNames() []string {
+	fls, err := fs.Glob(EmbedWts, "*.wts.gz")
+	if err != nil {
+		return nil
+	}
+	nms := make([]string, len(fls))
+	for i, fl := range fls {
+		nms[i] = strings.TrimSuffix(fl, ".wts.gz")
+	}
+	return nms
+}
+
+// IsEmbedWts returns true if given name is one of the embedded trained weights
+func IsEmbedWts(name string) bool {
+	for _, nm := range EmbedWtsNames() {
+		if nm == name {
+			return true
+		}
+	}
+	return false
+}
+
+// OpenWts opens weights of given name, which is either one of the embedded
+// trained weights (see EmbedWtsNames) or the path to a .wts or .wts.gz file.
+func (ss *Sim) OpenWts(name string) error {
+	if !IsEmbedWts(name) {
+		return ss.Net.OpenWtsJSON(gi.FileName(name))
+	}
+	f, err := EmbedWts.Open(name + ".wts.gz")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	return ss.Net.ReadWtsJSON(gz)
+}
+
+// OpenWtsFile opens weights from given file, and sets it as the TrainedWts
+// -- when called with giv.CallMethod it will auto-prompt for filename
+func (ss *Sim) OpenWtsFile(filename gi.FileName) {
+	ss.TrainedWts = string(filename)
+	ss.OpenTrainedWts()
+}
+
+// ChooseTrainedWts pops up a chooser for the TrainedWts among the embedded
+// weights, or a weights file, and then opens them.
+func (ss *Sim) ChooseTrainedWts(vp *gi.Viewport2D) {
+	fileItem := "Weights File..."
+	nms := append(EmbedWtsNames(), fileItem)
+	gi.StringsChooserPopup(nms, ss.TrainedWts, vp, func(recv, send ki.Ki, sig int64, data interface{}) {
+		ac := send.(*gi.Action)
+		if ac.Text == fileItem {
+			giv.CallMethod(ss, "OpenWtsFile", vp)
+			return
+		}
+		ss.TrainedWts = ac.Text
+		ss.OpenTrainedWts()
+		vp.SetNeedsFullRender()
+	})
+}